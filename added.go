@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// addedItem is a PR or issue added to a project during the run, as written by -addedfile.
+type addedItem struct {
+	Type    string `json:"type"`
+	Repo    string `json:"repo"`
+	Number  int    `json:"number"`
+	URL     string `json:"url"`
+	Project int    `json:"project"`
+}
+
+// addedLog collects the items added to projects.  Additions may run concurrently with
+// -addconcurrency, so it is safe for concurrent use.
+type addedLog struct {
+	mu    sync.Mutex
+	items []addedItem
+}
+
+func (l *addedLog) add(item addedItem) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = append(l.items, item)
+}
+
+// writeAddedFile writes the items in log to path, sorted by repository, number and project.  A
+// path ending in .json gets a JSON list of the items; any other path gets one "owner/repo#number
+// URL" line per PR or issue, however many projects it was added to.
+func writeAddedFile(path string, log *addedLog) error {
+	items := slices.Clone(log.items)
+	slices.SortFunc(items, func(a, b addedItem) int {
+		return cmp.Or(cmp.Compare(a.Repo, b.Repo), cmp.Compare(a.Number, b.Number), cmp.Compare(a.Project, b.Project))
+	})
+
+	var b bytes.Buffer
+	if strings.HasSuffix(path, ".json") {
+		if items == nil {
+			items = []addedItem{}
+		}
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error writing added file: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	} else {
+		for i, item := range items {
+			if i > 0 && item.Repo == items[i-1].Repo && item.Number == items[i-1].Number {
+				continue
+			}
+			fmt.Fprintf(&b, "%s#%d %s\n", item.Repo, item.Number, item.URL)
+		}
+	}
+	if err := writeFileAtomic(path, b.Bytes()); err != nil {
+		return fmt.Errorf("error writing added file: %w", err)
+	}
+	return nil
+}
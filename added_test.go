@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAddedFile(t *testing.T) {
+	var log addedLog
+	log.add(addedItem{Type: "pr", Repo: "rancher/rancher", Number: 12, URL: "https://github.com/rancher/rancher/pull/12", Project: 112})
+	log.add(addedItem{Type: "issue", Repo: "rancher/dashboard", Number: 7, URL: "https://github.com/rancher/dashboard/issues/7", Project: 79})
+	log.add(addedItem{Type: "pr", Repo: "rancher/rancher", Number: 12, URL: "https://github.com/rancher/rancher/pull/12", Project: 79})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "added.txt")
+	if err := writeAddedFile(path, &log); err != nil {
+		t.Fatalf("writeAddedFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "rancher/dashboard#7 https://github.com/rancher/dashboard/issues/7\nrancher/rancher#12 https://github.com/rancher/rancher/pull/12\n"
+	if string(data) != want {
+		t.Errorf("added file = %q, want %q", data, want)
+	}
+
+	path = filepath.Join(dir, "added.json")
+	if err := writeAddedFile(path, &log); err != nil {
+		t.Fatalf("writeAddedFile() error = %v", err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []addedItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatalf("error parsing added file: %v", err)
+	}
+	if len(items) != 3 || items[1].Project != 79 || items[2].Project != 112 {
+		t.Errorf("added items = %+v", items)
+	}
+}
+
+func TestWriteAddedFileEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "added.json")
+	if err := writeAddedFile(path, &addedLog{}); err != nil {
+		t.Fatalf("writeAddedFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[]\n" {
+		t.Errorf("added file = %q, want an empty list", data)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"publicprs/pkg/publicprs"
+)
+
+// checkTokenScopes warns when a classic personal access token lacks the scopes the run needs, or
+// fails with -strictscopes.  Without read:org, GitHub silently leaves private members out of
+// organization member lists, so their PRs would be reported as external.
+func checkTokenScopes(ctx context.Context, client *publicprs.Client, cfg config) error {
+	scopes, known, err := publicprs.TokenScopes(ctx, client)
+	if err != nil {
+		return err
+	}
+	if !known {
+		slog.Debug("Token scopes cannot be inspected, skipping the scope check")
+		return nil
+	}
+
+	missing := missingScopes(cfg, scopes)
+	if len(missing) == 0 {
+		return nil
+	}
+	if cfg.strictScopes {
+		return fmt.Errorf("the GitHub token is missing the scopes %s", strings.Join(missing, ", "))
+	}
+	slog.Warn("The GitHub token is missing scopes; member lists may be incomplete and members' PRs reported as external", "missing", missing, "scopes", scopes)
+	return nil
+}
+
+// missingScopes returns the scopes the run needs that are missing from scopes: read:org, and project
+// with -addtoproject or -prune, or just read:project for a dry run, which only reads the project.
+func missingScopes(cfg config, scopes []string) []string {
+	required := []string{"read:org"}
+	if cfg.addToProject || cfg.prune {
+		scope := "project"
+		if cfg.dryRun {
+			scope = "read:project"
+		}
+		required = append(required, scope)
+	}
+	// Commenting on, labeling and requesting reviews of PRs needs public_repo, or repo for private
+	// repositories
+	if (cfg.comment != "" || len(cfg.reviewers) > 0 || cfg.reviewersFile != "" || cfg.addLabel != "") && !cfg.dryRun {
+		required = append(required, "public_repo")
+	}
+	var missing []string
+	for _, scope := range required {
+		if !publicprs.HasScope(scopes, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// resolveToken returns the token used to talk to GitHub.  GitHub App credentials take precedence
+// and are exchanged for an installation token; otherwise the personal access token is taken from
+// -token, read from -tokenfile, or taken from GITHUB_TOKEN, in that order.
+func resolveToken(ctx context.Context, cfg config, client *http.Client, restURL string) (string, error) {
+	if cfg.appID == "" && cfg.installationID == "" && cfg.appKeyFile == "" {
+		if cfg.tokenArg != "" {
+			if cfg.tokenFile != "" {
+				return "", errors.New("-token and -tokenfile cannot be used together")
+			}
+			// Command lines are visible to other users of the host and end up in shell history
+			slog.Warn("Passing the token with -token is discouraged, it can leak through the process list or shell history; prefer GITHUB_TOKEN or -tokenfile")
+			return cfg.tokenArg, nil
+		}
+		if cfg.tokenFile != "" {
+			return readTokenFile(cfg.tokenFile)
+		}
+		if cfg.token == "" {
+			return "", fmt.Errorf("%w: GITHUB_TOKEN, -token, -tokenfile or GitHub App credentials (-appid, -installationid, -appkey) are required", publicprs.ErrTokenMissing)
+		}
+		return cfg.token, nil
+	}
+
+	if cfg.appID == "" || cfg.installationID == "" || cfg.appKeyFile == "" {
+		return "", errors.New("GitHub App authentication requires -appid, -installationid and -appkey")
+	}
+	appID, err := strconv.ParseInt(cfg.appID, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid -appid %q: %w", cfg.appID, err)
+	}
+	installationID, err := strconv.ParseInt(cfg.installationID, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid -installationid %q: %w", cfg.installationID, err)
+	}
+	key, err := os.ReadFile(cfg.appKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading GitHub App private key: %w", err)
+	}
+
+	token, err := publicprs.AppInstallationToken(ctx, client, restURL, appID, installationID, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitHub App installation token: %w", err)
+	}
+	return token, nil
+}
+
+// readTokenFile reads a personal access token from path, such as a mounted secret, ignoring
+// surrounding whitespace.
+func readTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading -tokenfile: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("invalid -tokenfile %s: the file is empty", path)
+	}
+	return token, nil
+}
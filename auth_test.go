@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte("  ghp_secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token, err := readTokenFile(path)
+	if err != nil || token != "ghp_secret" {
+		t.Errorf("readTokenFile() = %q, %v, want the trimmed token", token, err)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readTokenFile(empty); err == nil {
+		t.Error("readTokenFile() of an empty file succeeded, want an error")
+	}
+	if _, err := readTokenFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("readTokenFile() of a missing file succeeded, want an error")
+	}
+}
+
+func TestResolveTokenPrecedence(t *testing.T) {
+	ctx := context.Background()
+	token, err := resolveToken(ctx, config{token: "env-token", tokenArg: "flag-token"}, nil, "")
+	if err != nil || token != "flag-token" {
+		t.Errorf("resolveToken() = %q, %v, want the -token value", token, err)
+	}
+	token, err = resolveToken(ctx, config{token: "env-token"}, nil, "")
+	if err != nil || token != "env-token" {
+		t.Errorf("resolveToken() = %q, %v, want GITHUB_TOKEN", token, err)
+	}
+	if _, err := resolveToken(ctx, config{tokenArg: "flag-token", tokenFile: "token"}, nil, ""); err == nil {
+		t.Error("resolveToken() with -token and -tokenfile succeeded, want an error")
+	}
+	if _, err := resolveToken(ctx, config{}, nil, ""); err == nil {
+		t.Error("resolveToken() without a token succeeded, want an error")
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// memberCachePath returns the file used to cache the member list of an organization or team of the
+// GitHub server at host.  Each server gets its own directory, as organizations of GitHub Enterprise
+// Server instances can share names with github.com ones.
+func memberCachePath(host, source string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	host = strings.ReplaceAll(strings.ToLower(host), ":", "_")
+	return filepath.Join(dir, "publicprs", host, "members-"+strings.ReplaceAll(strings.ToLower(source), "/", "_")+".json"), nil
+}
+
+// loadCachedMembers returns the cached member list for an organization or team if it is younger than ttl.
+// The second return value is false when the cache is disabled, missing, stale or unreadable.
+func loadCachedMembers(host, source string, ttl time.Duration, refresh bool) (map[string]bool, bool) {
+	if ttl <= 0 || refresh {
+		return nil, false
+	}
+	path, err := memberCachePath(host, source)
+	if err != nil {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > ttl {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var logins []string
+	if err := json.Unmarshal(data, &logins); err != nil {
+		slog.Warn("Ignoring unreadable member cache", "path", path, "err", err)
+		return nil, false
+	}
+	members := make(map[string]bool, len(logins))
+	for _, login := range logins {
+		members[login] = true
+	}
+	slog.Info("Loaded cached members", "source", describeSource(source), "count", len(members))
+	return members, true
+}
+
+// saveCachedMembers writes the member list of an organization or team to the on-disk cache.
+func saveCachedMembers(host, source string, members map[string]bool) error {
+	path, err := memberCachePath(host, source)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	logins := make([]string, 0, len(members))
+	for login := range members {
+		logins = append(logins, login)
+	}
+	slices.Sort(logins)
+	data, err := json.Marshal(logins)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// projectCacheTTL is how long a cached project ID is used.  A project keeps its global ID for its
+// whole life, so this only bounds how long a deleted and recreated project goes unnoticed.
+const projectCacheTTL = 30 * 24 * time.Hour
+
+// cachedProjectID is the global ID of a project, as cached by saveCachedProjectIDs.
+type cachedProjectID struct {
+	ID        string    `json:"id"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// projectCachePath returns the file caching the global IDs of projects, next to the member lists.
+func projectCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "publicprs", "projects.json"), nil
+}
+
+// projectCacheKey identifies a project of owner on the GitHub server at host in the project ID
+// cache, as the same owner and number can exist on github.com and a GitHub Enterprise Server.
+func projectCacheKey(host, owner string, number int) string {
+	return fmt.Sprintf("%s/%s/%d", strings.ToLower(host), strings.ToLower(owner), number)
+}
+
+// loadCachedProjectIDs returns the cached project IDs by projectCacheKey.  The map is empty when
+// the cache is missing or unreadable.
+func loadCachedProjectIDs() map[string]cachedProjectID {
+	ids := make(map[string]cachedProjectID)
+	path, err := projectCachePath()
+	if err != nil {
+		return ids
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ids
+	}
+	if err := json.Unmarshal(data, &ids); err != nil {
+		slog.Warn("Ignoring unreadable project cache", "path", path, "err", err)
+		return make(map[string]cachedProjectID)
+	}
+	return ids
+}
+
+// saveCachedProjectIDs writes the project IDs to the on-disk cache.
+func saveCachedProjectIDs(ids map[string]cachedProjectID) error {
+	path, err := projectCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
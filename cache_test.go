@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCachedMembersPerHost(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	if err := saveCachedMembers("api.github.com", "rancher", map[string]bool{"jdoe": true}); err != nil {
+		t.Fatalf("saveCachedMembers() error = %v", err)
+	}
+	if members, ok := loadCachedMembers("api.github.com", "rancher", time.Hour, false); !ok || !members["jdoe"] {
+		t.Errorf("loadCachedMembers() = %v, %v, want jdoe", members, ok)
+	}
+	if members, ok := loadCachedMembers("ghe.example.com:8443", "rancher", time.Hour, false); ok {
+		t.Errorf("loadCachedMembers() of another host = %v, want no cached list", members)
+	}
+}
+
+func TestCachedProjectIDs(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	if ids := loadCachedProjectIDs(); len(ids) != 0 {
+		t.Fatalf("loadCachedProjectIDs() without a cache = %v, want none", ids)
+	}
+
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ids := map[string]cachedProjectID{
+		projectCacheKey("api.github.com", "Rancher", 79):  {ID: "PVT_kwDOAA", FetchedAt: fetchedAt},
+		projectCacheKey("ghe.example.com", "rancher", 79): {ID: "PVT_ghe", FetchedAt: fetchedAt},
+	}
+	if err := saveCachedProjectIDs(ids); err != nil {
+		t.Fatalf("saveCachedProjectIDs() error = %v", err)
+	}
+
+	cached := loadCachedProjectIDs()
+	got := cached[projectCacheKey("api.github.com", "rancher", 79)]
+	if got.ID != "PVT_kwDOAA" || !got.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("cached project ID = %+v", got)
+	}
+	// The same owner and number on another server is another project
+	if got := cached[projectCacheKey("GHE.example.com", "rancher", 79)]; got.ID != "PVT_ghe" {
+		t.Errorf("cached project ID of the GitHub Enterprise Server = %+v, want PVT_ghe", got)
+	}
+}
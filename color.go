@@ -0,0 +1,49 @@
+package main
+
+import "os"
+
+// ANSI escape sequences used to color the text listing.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// palette colors parts of the text listing, or leaves them as they are when disabled.
+type palette struct {
+	enabled bool
+}
+
+// newPalette returns the palette of the run: colors are only used for the text format written to
+// stdout, when stdout is a terminal and neither -nocolor nor the NO_COLOR environment variable
+// (https://no-color.org) is set.
+func newPalette(cfg config) palette {
+	if cfg.noColor || os.Getenv("NO_COLOR") != "" || cfg.format != "text" || cfg.outFile != "" {
+		return palette{}
+	}
+	return palette{enabled: isTerminal(os.Stdout)}
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p palette) paint(code, s string) string {
+	if !p.enabled || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// number colors a PR reference such as "PR #12".
+func (p palette) number(s string) string { return p.paint(ansiBold+ansiCyan, s) }
+
+// author colors an author login.
+func (p palette) author(s string) string { return p.paint(ansiYellow, s) }
+
+// stale colors the marker of a stale PR.
+func (p palette) stale(s string) string { return p.paint(ansiBold+ansiRed, s) }
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestPalette(t *testing.T) {
+	colors := palette{enabled: true}
+	if got, want := colors.number("PR #12"), "\x1b[1m\x1b[36mPR #12\x1b[0m"; got != want {
+		t.Errorf("number() = %q, want %q", got, want)
+	}
+	if got := colors.stale(""); got != "" {
+		t.Errorf("stale() of an empty marker = %q, want it empty", got)
+	}
+	if got := (palette{}).author("jdoe"); got != "jdoe" {
+		t.Errorf("author() with colors disabled = %q, want jdoe", got)
+	}
+
+	// Colors never reach other formats, files or pipes, and NO_COLOR disables them
+	t.Setenv("NO_COLOR", "")
+	for _, cfg := range []config{{format: "json"}, {format: "markdown"}, {format: "text", outFile: "report.txt"}, {format: "text", noColor: true}} {
+		if newPalette(cfg).enabled {
+			t.Errorf("newPalette(%+v) enabled colors", cfg)
+		}
+	}
+	t.Setenv("NO_COLOR", "1")
+	if newPalette(config{format: "text"}).enabled {
+		t.Error("newPalette() enabled colors with NO_COLOR set")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/template"
+
+	"publicprs/pkg/publicprs"
+)
+
+// parseCommentTemplate parses the -comment template, which is executed with the PR, so fields such
+// as {{.Author}}, {{.Number}} and {{.Title}} can be used.  Executing it on an example PR catches
+// references to unknown fields before anything is posted.
+func parseCommentTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("comment").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -comment: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, publicprs.PullRequest{}); err != nil {
+		return nil, fmt.Errorf("invalid -comment: %w", err)
+	}
+	return tmpl, nil
+}
+
+// commentOnPR posts the -comment on a PR that was just added to a project, describing it on w, or
+// only describes it in a dry run.  Failures are logged and don't stop the run.
+func commentOnPR(ctx context.Context, client *publicprs.Client, cfg config, w io.Writer, tmpl *template.Template, pr publicprs.PullRequest) {
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, pr); err != nil {
+		slog.Error("Error rendering comment", "pr", pr.Number, "err", err)
+		return
+	}
+	if cfg.dryRun {
+		fmt.Fprintf(w, "Would comment on PR #%d (dry run)\n", pr.Number)
+		return
+	}
+	prID, err := publicprs.ResolvePullRequestID(ctx, client, pr)
+	if err == nil {
+		_, err = publicprs.AddComment(ctx, client, prID, body.String())
+	}
+	if err != nil {
+		slog.Error("Error commenting on PR", "pr", pr.Number, "err", err)
+		return
+	}
+	fmt.Fprintf(w, "Commented on PR #%d\n", pr.Number)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"publicprs/pkg/publicprs"
+)
+
+func TestParseCommentTemplate(t *testing.T) {
+	tmpl, err := parseCommentTemplate("Thanks @{{.Author}}, #{{.Number}} is on our board")
+	if err != nil {
+		t.Fatalf("parseCommentTemplate() error = %v", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, publicprs.PullRequest{Author: "jdoe", Number: 12}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Thanks @jdoe, #12 is on our board"; b.String() != want {
+		t.Errorf("comment = %q, want %q", b.String(), want)
+	}
+
+	for _, text := range []string{"Thanks {{.Author", "Thanks {{.Login}}"} {
+		if _, err := parseCommentTemplate(text); err == nil {
+			t.Errorf("parseCommentTemplate(%q) succeeded, want an error", text)
+		}
+	}
+}
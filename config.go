@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// applyConfigFile reads a YAML or JSON file whose keys are flag names and sets every flag that
+// wasn't passed on the command line to the file's value, so command-line flags win.
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var values map[string]string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		values, err = parseJSONConfig(data)
+	} else {
+		values, err = parseYAMLConfig(data)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	for name, value := range values {
+		if name == "config" || fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown option %q in config file %s", name, path)
+		}
+		if set[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for %q in config file %s: %w", value, name, path, err)
+		}
+	}
+
+	return nil
+}
+
+// parseJSONConfig flattens a JSON object into flag values.  Lists are joined with commas.
+func parseJSONConfig(data []byte) (map[string]string, error) {
+	var raw map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case map[string]interface{}, nil:
+			return nil, fmt.Errorf("unsupported value for %q", key)
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// parseYAMLConfig reads the flat subset of YAML that config files need: "key: value" pairs,
+// comments, quoted strings, and lists written either inline ([a, b]) or as "- item" lines.
+func parseYAMLConfig(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	var listKey string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(stripYAMLComment(scanner.Text()))
+		if line == "" || line == "---" {
+			continue
+		}
+
+		if item, ok := strings.CutPrefix(line, "- "); ok || line == "-" {
+			if listKey == "" {
+				return nil, fmt.Errorf("line %d: list item without a key", lineNumber)
+			}
+			item, err := unquoteYAML(strings.TrimSpace(item))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			if values[listKey] != "" {
+				item = values[listKey] + "," + item
+			}
+			values[listKey] = item
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNumber)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		listKey = ""
+
+		switch {
+		case value == "":
+			// The values follow as "- item" lines
+			listKey = key
+			values[key] = ""
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			var items []string
+			for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+				if item = strings.TrimSpace(item); item == "" {
+					continue
+				}
+				item, err := unquoteYAML(item)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				items = append(items, item)
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			unquoted, err := unquoteYAML(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			values[key] = unquoted
+		}
+	}
+
+	return values, scanner.Err()
+}
+
+// stripYAMLComment removes a trailing "# comment" that isn't inside quotes.
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquoteYAML removes the quotes around a single or double quoted YAML scalar.
+func unquoteYAML(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+	return value, nil
+}
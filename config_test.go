@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{
+			name: "yaml",
+			file: "publicprs.yaml",
+			data: `# triage settings
+owner: rancher
+repo:
+  - rancher
+  - "dashboard"
+orgs: [rancher, SUSE]
+labels: "needs-review"  # only triage-ready PRs
+includebots: true
+project: 12
+`,
+		},
+		{
+			name: "json",
+			file: "publicprs.json",
+			data: `{"owner": "rancher", "repo": ["rancher", "dashboard"], "orgs": "rancher,SUSE", "labels": "needs-review", "includebots": true, "project": 12}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			owner := fs.String("owner", "", "")
+			repo := fs.String("repo", "", "")
+			orgs := fs.String("orgs", "", "")
+			labels := fs.String("labels", "", "")
+			includeBots := fs.Bool("includebots", false, "")
+			project := fs.Int("project", 0, "")
+			if err := fs.Parse([]string{"-owner", "SUSE"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := applyConfigFile(fs, path); err != nil {
+				t.Fatalf("applyConfigFile() error = %v", err)
+			}
+
+			if *owner != "SUSE" {
+				t.Errorf("owner = %q, command-line value should win", *owner)
+			}
+			if *repo != "rancher,dashboard" || *orgs != "rancher,SUSE" || *labels != "needs-review" || !*includeBots || *project != 12 {
+				t.Errorf("unexpected values repo=%q orgs=%q labels=%q includebots=%v project=%d", *repo, *orgs, *labels, *includeBots, *project)
+			}
+		})
+	}
+}
+
+func TestApplyConfigFileUnknownOption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "publicprs.yaml")
+	if err := os.WriteFile(path, []byte("nosuchflag: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := applyConfigFile(fs, path); err == nil {
+		t.Fatal("applyConfigFile() expected an error for an unknown option")
+	}
+}
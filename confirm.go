@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"publicprs/pkg/publicprs"
+)
+
+// confirmer asks before each PR is added to a project, for -confirm.
+type confirmer struct {
+	in  *bufio.Reader
+	out io.Writer
+	// all is set once every remaining PR was accepted, and quit once the user stopped adding PRs
+	all  bool
+	quit bool
+}
+
+// newConfirmer returns a confirmer reading answers from in and writing prompts to out.
+func newConfirmer(in io.Reader, out io.Writer) *confirmer {
+	return &confirmer{in: bufio.NewReader(in), out: out}
+}
+
+// confirm asks whether pr should be added to project number, and reports the answer: y adds it,
+// n skips it, a adds it and every following PR without asking, and q skips it and every following
+// PR.  The end of the input counts as q.
+func (c *confirmer) confirm(pr publicprs.PullRequest, number int) bool {
+	return c.ask(fmt.Sprintf("PR #%d (%s) by %s", pr.Number, pr.NameWithOwner(), pr.Author), number)
+}
+
+// confirmIssue asks whether issue should be added to project number, like confirm.
+func (c *confirmer) confirmIssue(issue publicprs.Issue, number int) bool {
+	return c.ask(fmt.Sprintf("Issue #%d (%s) by %s", issue.Number, issue.NameWithOwner(), issue.Author), number)
+}
+
+// ask asks whether the content described by description should be added to project number.
+func (c *confirmer) ask(description string, number int) bool {
+	for {
+		if c.all || c.quit {
+			return c.all
+		}
+		fmt.Fprintf(c.out, "Add %s to project %d? [y/n/a/q] ", description, number)
+		line, err := c.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(c.out)
+			c.quit = true
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		case "a", "all":
+			c.all = true
+		case "q", "quit":
+			c.quit = true
+		default:
+			fmt.Fprintln(c.out, "Please answer y (yes), n (no), a (all) or q (quit)")
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"publicprs/pkg/publicprs"
+)
+
+func TestConfirmer(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []bool
+	}{
+		{name: "yes and no", input: "y\nn\nyes\n", want: []bool{true, false, true}},
+		{name: "all", input: "n\na\n", want: []bool{false, true, true}},
+		{name: "quit", input: "y\nq\n", want: []bool{true, false, false}},
+		{name: "invalid answer is asked again", input: "maybe\ny\nn\nn\n", want: []bool{true, false, false}},
+		{name: "end of input quits", input: "y\n", want: []bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConfirmer(strings.NewReader(tt.input), io.Discard)
+			var got []bool
+			for i := range tt.want {
+				got = append(got, c.confirm(publicprs.PullRequest{Owner: "rancher", Repo: "rancher", Number: i + 1, Author: "jdoe"}, 79))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("answers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// dumpTransport writes every request it sends and the raw body of every response to stderr, or to
+// numbered files in a directory, for -dumpresponses.  Credentials in the request headers are
+// scrubbed before they are written.
+type dumpTransport struct {
+	base http.RoundTripper
+	// dir is the directory the exchanges are written to, or empty for stderr
+	dir string
+	seq atomic.Int64
+	mu  sync.Mutex
+}
+
+// sensitiveHeaders are the request headers replaced by a placeholder in dumps.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// newDumpTransport wraps base so each exchange is dumped to dest, "-" meaning stderr and anything
+// else a directory, which is created if needed.
+func newDumpTransport(base http.RoundTripper, dest string) (*dumpTransport, error) {
+	t := &dumpTransport{base: base}
+	if dest != "-" {
+		if err := os.MkdirAll(dest, 0o700); err != nil {
+			return nil, fmt.Errorf("error creating -dumpresponses directory: %w", err)
+		}
+		t.dir = dest
+	}
+	return t, nil
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := t.seq.Add(1)
+	var reqBody []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+	t.write(n, "request.txt", dumpRequest(req, reqBody))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n", resp.Status)
+	buf.Write(respBody)
+	t.write(n, "response.txt", buf.Bytes())
+	return resp, nil
+}
+
+// dumpRequest formats req and its body with the sensitive headers scrubbed.
+func dumpRequest(req *http.Request, body []byte) []byte {
+	header := req.Header.Clone()
+	for _, name := range sensitiveHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, "[REDACTED]")
+		}
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n", req.Method, req.URL)
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(&buf, "%s: %s\n", name, value)
+		}
+	}
+	buf.WriteString("\n")
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// write dumps one half of exchange n.  Failures are logged but never fail the request.
+func (t *dumpTransport) write(n int64, name string, data []byte) {
+	if t.dir != "" {
+		path := filepath.Join(t.dir, fmt.Sprintf("%04d-%s", n, name))
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			slog.Warn("Unable to dump response", "path", path, "err", err)
+		}
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "=== %d %s ===\n%s\n", n, name, data)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"viewer":{"login":"octocat"}}}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	transport, err := newDumpTransport(http.DefaultTransport, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/graphql", strings.NewReader(`{"query":"{viewer{login}}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"data":{"viewer":{"login":"octocat"}}}` {
+		t.Errorf("response body = %q, want it passed through unchanged", body)
+	}
+
+	request, err := os.ReadFile(filepath.Join(dir, "0001-request.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(request), "secret-token") {
+		t.Errorf("request dump leaks the token:\n%s", request)
+	}
+	if !strings.Contains(string(request), "Authorization: [REDACTED]") || !strings.Contains(string(request), `{"query":"{viewer{login}}"}`) {
+		t.Errorf("request dump = %q, want the scrubbed header and the body", request)
+	}
+	response, err := os.ReadFile(filepath.Join(dir, "0001-response.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(response), `"login":"octocat"`) {
+		t.Errorf("response dump = %q, want the raw body", response)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"publicprs/pkg/publicprs"
+)
+
+// prLabeler applies the -addlabel label to newly added PRs.  The label's ID differs in every
+// repository, so it is looked up once per repository.  It is safe for concurrent use.
+type prLabeler struct {
+	name string
+
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+func newPRLabeler(name string) *prLabeler {
+	return &prLabeler{name: name, ids: make(map[string]string)}
+}
+
+// labelID returns the global ID of the label in the owner/repo repository, failing when the
+// repository has no such label.
+func (l *prLabeler) labelID(ctx context.Context, client *publicprs.Client, owner, repo string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := owner + "/" + repo
+	if id, ok := l.ids[key]; ok {
+		return id, nil
+	}
+	id, err := publicprs.LabelID(ctx, client, owner, repo, l.name)
+	if err != nil {
+		return "", err
+	}
+	l.ids[key] = id
+	return id, nil
+}
+
+// apply labels pr, which was just added to a project, describing it on w, or only describes it in
+// a dry run.  PRs already carrying the label are left alone.  Failures are logged and don't stop
+// the run.
+func (l *prLabeler) apply(ctx context.Context, client *publicprs.Client, cfg config, w io.Writer, pr publicprs.PullRequest) {
+	if slices.ContainsFunc(pr.Labels, func(label string) bool { return strings.EqualFold(label, l.name) }) {
+		slog.Debug("Not labeling PR, it already has the label", "pr", pr.Number, "label", l.name)
+		return
+	}
+	if cfg.dryRun {
+		fmt.Fprintf(w, "Would label PR #%d %s (dry run)\n", pr.Number, l.name)
+		return
+	}
+	labelID, err := l.labelID(ctx, client, pr.Owner, pr.Repo)
+	if err != nil {
+		slog.Error("Error labeling PR", "pr", pr.Number, "label", l.name, "err", err)
+		return
+	}
+	prID, err := publicprs.ResolvePullRequestID(ctx, client, pr)
+	if err == nil {
+		err = publicprs.AddLabels(ctx, client, prID, []string{labelID})
+	}
+	if err != nil {
+		slog.Error("Error labeling PR", "pr", pr.Number, "label", l.name, "err", err)
+		return
+	}
+	fmt.Fprintf(w, "Labeled PR #%d %s\n", pr.Number, l.name)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"publicprs/pkg/publicprs"
+)
+
+func TestPRLabelerDryRun(t *testing.T) {
+	l := newPRLabeler("external-contrib")
+	var b bytes.Buffer
+	cfg := config{dryRun: true}
+	l.apply(context.Background(), nil, cfg, &b, publicprs.PullRequest{Number: 4, Labels: []string{"bug"}})
+	l.apply(context.Background(), nil, cfg, &b, publicprs.PullRequest{Number: 5, Labels: []string{"External-Contrib"}})
+	if got, want := b.String(), "Would label PR #4 external-contrib (dry run)\n"; got != want {
+		t.Errorf("apply() wrote %q, want %q", got, want)
+	}
+}
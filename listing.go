@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"publicprs/pkg/publicprs"
+)
+
+// defaultListingTemplate is the -template used when none is given: the PR's number and author,
+// followed by one line per detail.
+const defaultListingTemplate = `
+{{stale .}}{{number .}} by {{author .}}
+Repo: {{.NameWithOwner}}
+Base: {{.BaseBranch}}
+Head: {{headRef .}}
+Title: {{.Title}}
+Milestone: {{milestone .}}
+Size: +{{.Additions}} -{{.Deletions}}
+Mergeable: {{mergeable .}}
+Checks: {{checks .}}
+Reviews: {{reviews .}}
+Assignees: {{logins .Assignees}}
+Reviewers: {{logins .RequestedReviewers}}
+Closes: {{issues .LinkedIssues}}
+Link: {{.URL}}
+`
+
+// sampleListingPR is the PR -template is tried on before the scan.  Every field is set, so templates
+// indexing its lists or formatting its times work as they will on real PRs.
+var sampleListingPR = publicprs.PullRequest{
+	ID: "PR_sample", Owner: "rancher", Repo: "rancher", Number: 1, Title: "Sample", Body: "Sample",
+	URL: "https://github.com/rancher/rancher/pull/1", BaseBranch: "main", HeadOwner: "octocat", HeadBranch: "fix",
+	CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	Author: "octocat", AuthorAssociation: "CONTRIBUTOR", AuthorOrgs: []string{"partner"}, Labels: []string{"bug"},
+	Mergeable: "MERGEABLE", ChecksState: "SUCCESS", State: "OPEN", Milestone: "v1.0", MilestoneNumber: 1,
+	Reviews: 1, ReviewDecision: "APPROVED", Assignees: []string{"maintainer"}, RequestedReviewers: []string{"reviewer"},
+	LinkedIssues: []publicprs.LinkedIssue{{Number: 2, Title: "Sample"}}, Additions: 1, Deletions: 1,
+}
+
+// parseListingTemplate parses the -template executed with each PR of the text listing, or
+// defaultListingTemplate when text is empty.  Besides the fields of the PR, templates can call the
+// helpers of the default template, which color their output with colors.  A newline is added to
+// templates not ending with one, so each PR gets its own line.  Executing the template on
+// sampleListingPR catches references to unknown fields before the scan.
+func parseListingTemplate(text string, colors palette) (*template.Template, error) {
+	if text == "" {
+		text = defaultListingTemplate
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	tmpl, err := template.New("listing").Funcs(template.FuncMap{
+		"stale":     func(pr publicprs.PullRequest) string { return colors.stale(staleMarker(pr)) },
+		"number":    func(pr publicprs.PullRequest) string { return colors.number(fmt.Sprintf("PR #%d", pr.Number)) },
+		"author":    func(pr publicprs.PullRequest) string { return colors.author(pr.Author) },
+		"headRef":   headRef,
+		"milestone": milestoneName,
+		"mergeable": mergeableStatus,
+		"checks":    checksStatus,
+		"reviews":   reviewStatus,
+		"logins":    loginList,
+		"issues":    issueList,
+		"body":      bodySummary,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, sampleListingPR); err != nil {
+		return nil, fmt.Errorf("invalid -template: %w", err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"publicprs/pkg/publicprs"
+)
+
+func TestParseListingTemplate(t *testing.T) {
+	pr := publicprs.PullRequest{
+		Owner: "rancher", Repo: "rancher", Number: 12, Title: "Fix the thing", Author: "jdoe",
+		BaseBranch: "main", HeadOwner: "jdoe", HeadBranch: "fix", Additions: 10, Deletions: 2,
+		URL: "https://github.com/rancher/rancher/pull/12", Stale: true, Assignees: []string{"alice"},
+		CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	// The default template keeps the layout of the text report
+	tmpl, err := parseListingTemplate("", palette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, pr); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("\n[STALE] PR #12 by jdoe\nRepo: rancher/rancher\nBase: main\nHead: %s\nTitle: Fix the thing\nMilestone: %s\nSize: +10 -2\nMergeable: %s\nChecks: %s\nReviews: %s\nAssignees: alice\nReviewers: %s\nCloses: %s\nLink: https://github.com/rancher/rancher/pull/12\n",
+		headRef(pr), milestoneName(pr), mergeableStatus(pr), checksStatus(pr), reviewStatus(pr), loginList(nil), issueList(nil))
+	if got := b.String(); got != want {
+		t.Errorf("default template = %q, want %q", got, want)
+	}
+
+	tmpl, err = parseListingTemplate(`{{.Number}} {{.Author}} {{.CreatedAt.Format "2006-01-02"}} {{.Title}}`, palette{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := tmpl.Execute(&b, pr); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "12 jdoe 2024-01-02 Fix the thing\n"; got != want {
+		t.Errorf("custom template = %q, want %q", got, want)
+	}
+
+	// Templates relying on fields being set are accepted, as a real PR may have them
+	tmpl, err = parseListingTemplate(`{{.Number}}{{if .Labels}} [{{index .Labels 0}}]{{end}}`, palette{})
+	if err != nil {
+		t.Fatalf("parseListingTemplate() of a template indexing the labels error = %v", err)
+	}
+	b.Reset()
+	if err := tmpl.Execute(&b, pr); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "12\n"; got != want {
+		t.Errorf("template indexing the labels = %q, want %q", got, want)
+	}
+
+	for _, text := range []string{"{{.Number", "{{.Reviewer}}"} {
+		if _, err := parseListingTemplate(text, palette{}); err == nil {
+			t.Errorf("parseListingTemplate(%q) succeeded, want an error", text)
+		}
+	}
+}
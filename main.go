@@ -2,386 +2,1055 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"io"
+	"log/slog"
 	"os"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
-	"github.com/machinebox/graphql"
-	"golang.org/x/oauth2"
+	"publicprs/pkg/publicprs"
 )
 
-type Member struct {
-	Login string `json:"login"`
+// errExternalPRsFound is returned by run with -failonmatch when external PRs were reported.
+var errExternalPRsFound = errors.New("external PRs found")
+
+// maxAddConcurrency caps -addconcurrency.  GitHub's secondary rate limits penalize bursts of
+// concurrent mutations, so only a few PRs are added at a time even when more are allowed.
+const maxAddConcurrency = 5
+
+// config holds the options collected from the command line.
+type config struct {
+	owner          string
+	projectOwner   string
+	repos          []string
+	repoFile       string
+	searchQuery    string
+	orgs           []string
+	teams          []string
+	externalOrgs   []string
+	includeBots    bool
+	includeIssues  bool
+	includeGhost   bool
+	includeBody    bool
+	botsToExclude  []string
+	addToProject   bool
+	projects       []string
+	labels         []string
+	baseBranches   []string
+	pathPrefixes   []string
+	requireAll     bool
+	memberCacheTTL time.Duration
+	refreshMembers bool
+	refreshProject bool
+	maxRetries     int
+	rateLimitMin   int
+	pageSize       int
+	rateLimitMode  string
+	timeout        time.Duration
+	httpTimeout    time.Duration
+	idleConns      int
+	idleTimeout    time.Duration
+	concurrency    int
+	addConcurrency int
+	collaborators  bool
+	extraMembers   string
+	annotateOrgs   []string
+	affiliation    string
+	baseURL        string
+	caCert         string
+	dumpResponses  string
+	dumpMembers    string
+	dryRun         bool
+	prune          bool
+	setStatus      string
+	pruneStatus    string
+	addedFile      string
+	comment        string
+	reviewers      []string
+	reviewersFile  string
+	addLabel       string
+	noColor        bool
+	template       string
+	groupBy        string
+	quiet          bool
+	anonymize      bool
+	failOnMatch    bool
+	countOnly      bool
+	preflight      bool
+	sortKey        string
+	metricsFile    string
+	slackWebhook   string
+	slackState     string
+	stateFile      string
+	newOnly        bool
+	confirm        bool
+	format         string
+	outFile        string
+	verbose        bool
+	strictScopes   bool
+	state          string
+	maxPRs         int
+	excludeDrafts  bool
+	onlyDrafts     bool
+	needsReview    bool
+	unassigned     bool
+	linkedOnly     bool
+	milestone      string
+	fromForks      bool
+	greenOnly      bool
+	minLines       int
+	maxLines       int
+	since          time.Duration
+	minAge         time.Duration
+	warnAge        time.Duration
+	by             string
+	authorPattern  string
+	associations   []string
+	token          string
+	tokenArg       string
+	tokenFile      string
+	appID          string
+	installationID string
+	appKeyFile     string
 }
 
 func main() {
 	owner := flag.String("owner", "rancher", "Repository owner")
-	repo := flag.String("repo", "rancher", "Repository name")
+	repo := flag.String("repo", "rancher", "Comma-separated list of repository names")
+	searchQuery := flag.String("searchquery", "", "GitHub search query selecting the PRs to scan instead of -repo, e.g. \"org:rancher is:open -label:triaged\"")
+	repoFile := flag.String("repofile", "", "File listing the repositories to scan as owner/repo, one per line, instead of -repo")
 	orgs := flag.String("orgs", "rancher,SUSE", "Comma-separated list of organizations")
+	teams := flag.String("teams", "", "Comma-separated list of teams (org/team-slug) whose members are internal")
+	externalOrgs := flag.String("externalorgs", "", "Comma-separated list of organizations whose members are not internal, even when listed in -orgs")
 	includeBots := flag.Bool("includebots", false, "Include PRs authored by bots")
+	includeIssues := flag.Bool("includeissues", false, "Also report open issues opened by users outside of the organizations, and add them to the project with -addtoproject")
+	includeBody := flag.Bool("includebody", false, "Fetch the description of each PR, in full in the JSON report and truncated in the text report")
+	includeGhost := flag.Bool("includeghost", false, "Include PRs whose author account has been deleted, reported as \"(deleted user)\"")
 	botsToExclude := flag.String("botstoexclude", "", "Comma-separated list of bots to exclude")
+	authorPattern := flag.String("authorpattern", "", "Regular expression; PRs whose author login matches it are skipped")
 	addToProject := flag.Bool("addtoproject", false, "Add matching PRs to the given project")
-	projectNumber := flag.Int("project", 79, "GitHub project number")
+	dryRun := flag.Bool("dryrun", false, "With -addtoproject or -prune, report what would change without changing the project")
+	prune := flag.Bool("prune", false, "Remove PRs whose authors are now members from the given project")
+	confirm := flag.Bool("confirm", false, "With -addtoproject, ask on the terminal before adding each PR")
+	comment := flag.String("comment", "", "With -addtoproject, comment on each PR newly added to a project; a Go template of the PR, e.g. \"Thanks {{.Author}}, we'll review #{{.Number}} soon\"")
+	requestReviewers := flag.String("requestreviewers", "", "With -addtoproject, comma-separated list of maintainers to request a review from, one per PR newly added to a project, rotating by PR number")
+	reviewersFile := flag.String("reviewersfile", "", "File listing more maintainers for -requestreviewers, one login per line")
+	addLabel := flag.String("addlabel", "", "With -addtoproject, apply this existing label, e.g. external-contrib, to each PR newly added to a project")
+	addedFile := flag.String("addedfile", "", "With -addtoproject, write the PRs added to projects to this file after a successful run, as JSON when it ends in .json")
+	pruneStatus := flag.String("prunestatus", "", "With -prune, only remove items whose single select field has this value, as field=option (e.g. \"Status=Needs Triage\")")
+	setStatus := flag.String("setstatus", "", "With -addtoproject, set a single select field of newly added items, as field=option (e.g. \"Status=Needs Triage\")")
+	projectOwner := flag.String("projectowner", "", "Organization or user owning the project (defaults to -owner)")
+	projects := flag.String("project", "79", "Comma-separated list of GitHub project numbers")
+	state := flag.String("state", "open", "State of the PRs to report: open, closed, merged or all")
+	maxPRs := flag.Int("maxprs", 0, "Stop fetching after this many PRs (0 means no limit)")
+	labels := flag.String("labels", "", "Comma-separated list of labels; only PRs with at least one of them are reported")
+	pathPrefix := flag.String("pathprefix", "", "Comma-separated list of path prefixes or globs (e.g. pkg/agent/**); only PRs changing a matching file are reported")
+	baseBranch := flag.String("basebranch", "", "Comma-separated list of base branches; only PRs targeting one of them are reported")
+	requireAll := flag.Bool("requireall", false, "Only report PRs that carry all of the given labels")
+	excludeDrafts := flag.Bool("excludedrafts", false, "Skip draft PRs")
+	needsReview := flag.Bool("needsreview", false, "Only report PRs without any review or review decision")
+	unassigned := flag.Bool("unassigned", false, "Only report PRs without any assignee")
+	minLines := flag.Int("minlines", 0, "Only report PRs changing at least this many lines (additions plus deletions)")
+	maxLines := flag.Int("maxlines", 0, "Only report PRs changing at most this many lines (0 means no limit)")
+	since := flag.Duration("since", 0, "Only report PRs created within this duration, e.g. 168h for the last week (0 means no limit)")
+	warnAge := flag.Duration("warnage", 0, "Flag PRs created longer ago than this as [STALE] in the report, e.g. 2160h (0 disables it)")
+	minAge := flag.Duration("minage", 0, "Only report PRs created at least this long ago, e.g. 720h to find stale PRs (0 means no limit)")
+	by := flag.String("by", "created", "Date that -since, -minage and -sort=created use: created or updated")
+	association := flag.String("association", "", "Comma-separated list of author associations, e.g. FIRST_TIME_CONTRIBUTOR; only PRs whose author has one of them are reported")
+	fromForks := flag.Bool("fromforks", false, "Only report PRs whose head repository is owned by someone other than the repository owner")
+	greenOnly := flag.Bool("greenonly", false, "Only report PRs that are mergeable and whose checks pass")
+	linkedOnly := flag.Bool("linkedonly", false, "Only report PRs that close at least one issue")
+	milestone := flag.String("milestone", "", "Only report PRs assigned to the milestone with this title or number")
+	onlyDrafts := flag.Bool("onlydrafts", false, "Only report draft PRs")
+	includeCollaborators := flag.Bool("includecollaborators", false, "Treat collaborators of the scanned repositories as internal")
+	extraMembers := flag.String("extramembers", "", "File listing additional internal logins, one per line")
+	annotateOrgs := flag.String("annotateorgs", "", "Comma-separated list of partner organizations; external authors who are public members of them are annotated in the report")
+	affiliation := flag.String("collaboratoraffiliation", "direct", "With -includecollaborators, which collaborators are internal: outside, direct or all")
+	memberCacheTTL := flag.Duration("membercachettl", time.Hour, "How long cached org member lists stay valid (0 disables the cache)")
+	refreshMembers := flag.Bool("refreshmembers", false, "Ignore cached org member lists and refetch them")
+	refreshProject := flag.Bool("refreshproject", false, "Ignore cached project IDs and look the projects up again")
+	maxRetries := flag.Int("maxretries", 5, "Maximum number of retries for rate limited requests and transient GitHub server errors")
+	rateLimitMin := flag.Int("ratelimitthreshold", 50, "Apply -ratelimitstrategy when fewer API requests than this remain (0 disables the check)")
+	pageSize := flag.Int("pagesize", publicprs.MaxPageSize, fmt.Sprintf("Number of PRs and members fetched per page, between 1 and %d", publicprs.MaxPageSize))
+	rateLimitMode := flag.String("ratelimitstrategy", "sleep", "What to do when the rate limit runs low: sleep until it resets, or abort")
+	sortKey := flag.String("sort", "created", "Sort the report by created, number or author; prefix with - for descending order")
+	failOnMatch := flag.Bool("failonmatch", false, "Exit with status 2 when at least one external PR is reported")
+	countOnly := flag.Bool("countonly", false, "Only print the number of external PRs passing the filters")
+	preflight := flag.Bool("preflight", false, "Check the token, repositories, organizations and projects, print a checklist and exit without scanning")
+	anonymize := flag.Bool("anonymize", false, "Replace author logins in the report with a stable hash")
+	quiet := flag.Bool("quiet", false, "Only print the summary line of the text report")
+	groupBy := flag.String("groupby", "", "Group the report; \"author\" lists each external author with their PRs")
+	format := flag.String("format", "text", "Report format: text, json or markdown")
+	noColor := flag.Bool("nocolor", false, "Don't color the text report, which is otherwise colored when stdout is a terminal and $NO_COLOR is unset")
+	listingTemplate := flag.String("template", "", "Go template executed with each PR of the text report, e.g. \"{{.Number}} {{.Author}} {{.Title}} {{.URL}}\" (defaults to the built-in layout)")
+	outFile := flag.String("out", "", "Write the report to this file instead of stdout")
+	stateFile := flag.String("statefile", "", "File recording the PRs reported by earlier runs, to tell which PRs are new")
+	newOnly := flag.Bool("newonly", false, "With -statefile, only report PRs that earlier runs did not report")
+	slackWebhook := flag.String("slackwebhook", os.Getenv("SLACK_WEBHOOK_URL"), "Slack incoming webhook URL to post newly reported PRs to (defaults to $SLACK_WEBHOOK_URL)")
+	slackState := flag.String("slackstate", "", "File recording the PRs already posted to Slack (defaults to a file in the user cache directory)")
+	metricsFile := flag.String("metricsfile", "", "Write Prometheus metrics about the run to this file, for the node_exporter textfile collector")
+	strictScopes := flag.Bool("strictscopes", false, "Fail instead of warning when the token lacks the read:org scope, or the project scope with -addtoproject or -prune")
+	verbose := flag.Bool("verbose", false, "Log debug messages, such as each page fetched from GitHub")
+	timeout := flag.Duration("timeout", 0, "Maximum duration of the whole run (0 means no limit)")
+	httpTimeout := flag.Duration("httptimeout", 15*time.Second, "Timeout of each HTTP request to GitHub")
+	idleConns := flag.Int("idleconns", 16, "Maximum number of idle connections kept open to each host, for reuse across requests")
+	idleTimeout := flag.Duration("idletimeout", 90*time.Second, "How long idle connections are kept open for reuse")
+	concurrency := flag.Int("concurrency", 4, "Number of organizations whose members are fetched concurrently")
+	addConcurrency := flag.Int("addconcurrency", 1, fmt.Sprintf("With -addtoproject, number of PRs added to projects concurrently (at most %d)", maxAddConcurrency))
+	baseURL := flag.String("baseurl", os.Getenv("GITHUB_API_URL"), "GitHub base URL, for GitHub Enterprise Server (defaults to $GITHUB_API_URL or public GitHub)")
 
-	flag.Parse()
-	ctx := context.Background()
+	tokenArg := flag.String("token", "", "GitHub token, used instead of GITHUB_TOKEN; discouraged as command lines can leak, prefer -tokenfile")
+	tokenFile := flag.String("tokenfile", "", "File containing the GitHub token, used instead of GITHUB_TOKEN")
+	appID := flag.String("appid", os.Getenv("GITHUB_APP_ID"), "GitHub App ID, to authenticate as an app installation instead of with GITHUB_TOKEN (defaults to $GITHUB_APP_ID)")
+	installationID := flag.String("installationid", os.Getenv("GITHUB_APP_INSTALLATION_ID"), "GitHub App installation ID (defaults to $GITHUB_APP_INSTALLATION_ID)")
+	appKeyFile := flag.String("appkey", os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"), "Path to the GitHub App private key PEM file (defaults to $GITHUB_APP_PRIVATE_KEY_FILE)")
 
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("GITHUB_TOKEN is required")
+	caCert := flag.String("cacert", "", "PEM bundle of additional CA certificates to trust, e.g. for a TLS-inspecting proxy")
+	dumpDefault := ""
+	if os.Getenv("PUBLICPRS_DEBUG") == "1" {
+		dumpDefault = "-"
 	}
+	dumpResponses := flag.String("dumpresponses", dumpDefault, "Write every GitHub API request, with credentials scrubbed, and its raw response to stderr (\"-\") or to files in this directory (defaults to \"-\" when $PUBLICPRS_DEBUG is 1)")
+	dumpMembers := flag.String("dumpmembers", "", "Write the logins considered internal, with the orgs, teams or files each comes from, as JSON to stderr (\"-\") or to this file, and exit without scanning")
+	configFile := flag.String("config", "", "Path to a YAML or JSON file whose keys mirror these flags; command-line flags take precedence")
 
-	var httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	))
-	httpClient.Timeout = 15 * time.Second
-	client := graphql.NewClient("https://api.github.com/graphql", graphql.WithHTTPClient(httpClient))
+	flag.Parse()
 
-	orgList := strings.Split(*orgs, ",")
-	botsToExcludeList := strings.Split(*botsToExclude, ",")
+	if *configFile != "" {
+		if err := applyConfigFile(flag.CommandLine, *configFile); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	}
 
-	// Get project global ID
-	projectGlobalID, err := getProjectV2ID(ctx, client, *owner, *projectNumber)
-	if err != nil {
-		log.Fatalf("Failed to fetch project ID: %v", err)
+	cfg := config{
+		owner:          *owner,
+		projectOwner:   *projectOwner,
+		repos:          strings.Split(*repo, ","),
+		repoFile:       *repoFile,
+		searchQuery:    *searchQuery,
+		orgs:           strings.Split(*orgs, ","),
+		teams:          splitList(*teams),
+		externalOrgs:   splitList(*externalOrgs),
+		includeBots:    *includeBots,
+		includeIssues:  *includeIssues,
+		includeGhost:   *includeGhost,
+		includeBody:    *includeBody,
+		botsToExclude:  strings.Split(*botsToExclude, ","),
+		addToProject:   *addToProject,
+		projects:       splitList(*projects),
+		labels:         splitList(*labels),
+		baseBranches:   splitList(*baseBranch),
+		pathPrefixes:   splitList(*pathPrefix),
+		requireAll:     *requireAll,
+		memberCacheTTL: *memberCacheTTL,
+		refreshMembers: *refreshMembers,
+		refreshProject: *refreshProject,
+		maxRetries:     *maxRetries,
+		rateLimitMin:   *rateLimitMin,
+		pageSize:       *pageSize,
+		rateLimitMode:  *rateLimitMode,
+		timeout:        *timeout,
+		httpTimeout:    *httpTimeout,
+		idleConns:      *idleConns,
+		idleTimeout:    *idleTimeout,
+		concurrency:    *concurrency,
+		addConcurrency: *addConcurrency,
+		collaborators:  *includeCollaborators,
+		extraMembers:   *extraMembers,
+		annotateOrgs:   splitList(*annotateOrgs),
+		affiliation:    *affiliation,
+		baseURL:        *baseURL,
+		caCert:         *caCert,
+		dumpResponses:  *dumpResponses,
+		dumpMembers:    *dumpMembers,
+		dryRun:         *dryRun,
+		prune:          *prune,
+		setStatus:      *setStatus,
+		pruneStatus:    *pruneStatus,
+		addedFile:      *addedFile,
+		comment:        *comment,
+		reviewers:      splitList(*requestReviewers),
+		reviewersFile:  *reviewersFile,
+		addLabel:       *addLabel,
+		noColor:        *noColor,
+		template:       *listingTemplate,
+		groupBy:        *groupBy,
+		quiet:          *quiet,
+		anonymize:      *anonymize,
+		failOnMatch:    *failOnMatch,
+		countOnly:      *countOnly,
+		preflight:      *preflight,
+		sortKey:        *sortKey,
+		metricsFile:    *metricsFile,
+		slackWebhook:   *slackWebhook,
+		slackState:     *slackState,
+		stateFile:      *stateFile,
+		newOnly:        *newOnly,
+		confirm:        *confirm,
+		format:         *format,
+		outFile:        *outFile,
+		verbose:        *verbose,
+		strictScopes:   *strictScopes,
+		state:          *state,
+		maxPRs:         *maxPRs,
+		excludeDrafts:  *excludeDrafts,
+		onlyDrafts:     *onlyDrafts,
+		needsReview:    *needsReview,
+		unassigned:     *unassigned,
+		linkedOnly:     *linkedOnly,
+		milestone:      strings.TrimSpace(*milestone),
+		fromForks:      *fromForks,
+		greenOnly:      *greenOnly,
+		minLines:       *minLines,
+		maxLines:       *maxLines,
+		since:          *since,
+		minAge:         *minAge,
+		warnAge:        *warnAge,
+		by:             *by,
+		authorPattern:  *authorPattern,
+		associations:   splitList(strings.ToUpper(*association)),
+		token:          os.Getenv("GITHUB_TOKEN"),
+		tokenArg:       *tokenArg,
+		tokenFile:      *tokenFile,
+		appID:          *appID,
+		installationID: *installationID,
+		appKeyFile:     *appKeyFile,
 	}
 
-	// Fetch organization members
-	members := make(map[string]bool)
-	for _, org := range orgList {
-		err := fetchOrgMembers(ctx, token, org, members)
-		if err != nil {
-			log.Fatalf("Error fetching members from %s organization: %v", org, err)
-		}
-		log.Printf("Fetched members from org %s.  Total members list is now: %d", org, len(members))
+	// Progress and diagnostics go to stderr so the report on stdout can be piped
+	level := slog.LevelInfo
+	if cfg.verbose {
+		level = slog.LevelDebug
 	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
 
-	// Fetch pull requests
-	cursor := ""
-	var pullRequests []struct {
-		Number    int
-		Title     string
-		URL       string
-		CreatedAt time.Time
-		Author    string
-	}
-
-	for {
-		req := graphql.NewRequest(`
-			query ($owner: String!, $repo: String!, $cursor: String) {
-				repository(owner: $owner, name: $repo) {
-					pullRequests(first: 100, after: $cursor, states: OPEN) {
-						nodes {
-							number
-							title
-							url
-							createdAt
-							author {
-								login
-							}
-						}
-						pageInfo {
-							endCursor
-							hasNextPage
-						}
-					}
-				}
-			}
-		`)
-		req.Var("owner", *owner)
-		req.Var("repo", *repo)
-		req.Var("cursor", cursor)
-
-		var resp struct {
-			Repository struct {
-				PullRequests struct {
-					Nodes []struct {
-						Number    int
-						Title     string
-						URL       string
-						CreatedAt string
-						Author    struct {
-							Login string
-						}
-					}
-					PageInfo struct {
-						EndCursor   string
-						HasNextPage bool
-					}
-				}
-			}
-		}
+	if cfg.projectOwner == "" {
+		cfg.projectOwner = cfg.owner
+	}
 
-		if err := client.Run(ctx, req, &resp); err != nil {
-			log.Fatalf("Error fetching PRs: %v", err)
-		}
+	// Teams replace whole-org membership unless -orgs is passed explicitly as well
+	if len(cfg.teams) > 0 && !isFlagSet("orgs") {
+		cfg.orgs = nil
+	}
+	cfg.orgs = internalOrgs(cfg.orgs, cfg.externalOrgs)
 
-		for _, pr := range resp.Repository.PullRequests.Nodes {
-			pullRequests = append(pullRequests, struct {
-				Number    int
-				Title     string
-				URL       string
-				CreatedAt time.Time
-				Author    string
-			}{
-				Number:    pr.Number,
-				Title:     pr.Title,
-				URL:       pr.URL,
-				CreatedAt: parseTime(pr.CreatedAt),
-				Author:    pr.Author.Login,
-			})
+	// A search replaces the default repository, and selects the states of the PRs itself
+	if cfg.searchQuery != "" {
+		if !isFlagSet("repo") {
+			cfg.repos = nil
 		}
-
-		if !resp.Repository.PullRequests.PageInfo.HasNextPage {
-			break
+		if isFlagSet("state") {
+			slog.Warn("-state is ignored with -searchquery, add is:open, is:closed or is:merged to the query instead")
 		}
-		cursor = resp.Repository.PullRequests.PageInfo.EndCursor
 	}
 
-	sort.Slice(pullRequests, func(i, j int) bool {
-		return pullRequests[i].CreatedAt.Before(pullRequests[j].CreatedAt)
-	})
-
-	fmt.Printf("PRs created by users outside of %s:\n", orgList)
-	fmt.Printf("-------------------------------------------")
-	for _, pr := range pullRequests {
-		if _, isMember := members[pr.Author]; !isMember {
-			if !*includeBots && slices.Contains(botsToExcludeList, pr.Author) {
-				continue
-			}
-			fmt.Printf("\nPR #%d by %s\nTitle: %s\nLink: %s\n", pr.Number, pr.Author, pr.Title, pr.URL)
+	ctx := context.Background()
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
 
-			if *addToProject {
-				added, err := addPRToProject(ctx, client, projectGlobalID, *owner, *repo, pr.Number)
-				if err != nil {
-					log.Printf("Error adding PR #%d to project: %v", pr.Number, err)
-				}
-				if added {
-					fmt.Printf("PR #%d added to project %v\n", pr.Number, *projectNumber)
-				} else {
-					fmt.Printf("PR #%d already in project %v\n", pr.Number, *projectNumber)
-				}
-			}
+	if err := run(ctx, cfg); err != nil {
+		code := exitCode(err)
+		if code != 2 {
+			slog.Error(err.Error())
 		}
+		os.Exit(code)
 	}
 }
 
-// parseTime parses the GitHub date-time format into time.Time
-func parseTime(dateTime string) time.Time {
-	t, err := time.Parse(time.RFC3339, dateTime)
-	if err != nil {
-		log.Fatalf("Error parsing date-time: %v", err)
+// exitCode returns the exit status for an error returned by run, so scripts can tell the failures
+// they may handle, such as a rate limit worth retrying later, from the others.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errExternalPRsFound):
+		return 2
+	case errors.Is(err, publicprs.ErrTokenMissing):
+		return 3
+	case errors.Is(err, publicprs.ErrRateLimited):
+		return 4
+	case errors.Is(err, publicprs.ErrProjectNotFound):
+		return 5
 	}
-	return t
+	return 1
 }
 
-// getProjectV2ID fetches the global ID for the ProjectV2
-func getProjectV2ID(ctx context.Context, client *graphql.Client, org string, projectNumber int) (string, error) {
-	req := graphql.NewRequest(`
-		query($org: String!, $projectNumber: Int!) {
-			organization(login: $org) {
-				projectV2(number: $projectNumber) {
-					id
-				}
+// run fetches the PRs for the configured repositories and reports the ones
+// authored by users outside of the configured organizations.
+func run(ctx context.Context, cfg config) error {
+	start := time.Now()
+	for _, team := range cfg.teams {
+		if org, slug, ok := strings.Cut(team, "/"); !ok || org == "" || slug == "" {
+			return fmt.Errorf("invalid team %q: expected org/team-slug", team)
+		}
+	}
+	if !slices.Contains([]string{"text", "json", "markdown"}, cfg.format) {
+		return fmt.Errorf("invalid -format %q: expected text, json or markdown", cfg.format)
+	}
+	if cfg.format == "markdown" && cfg.groupBy != "" {
+		return errors.New("-groupby cannot be used with -format markdown")
+	}
+	if cfg.template != "" && (cfg.format != "text" || cfg.groupBy != "") {
+		return errors.New("-template only applies to the text report, without -groupby")
+	}
+	if cfg.groupBy != "" && cfg.groupBy != "author" {
+		return fmt.Errorf("invalid -groupby %q: only \"author\" is supported", cfg.groupBy)
+	}
+	if cfg.collaborators && !slices.Contains([]string{"outside", "direct", "all"}, cfg.affiliation) {
+		return fmt.Errorf("invalid -collaboratoraffiliation %q: must be outside, direct or all", cfg.affiliation)
+	}
+	if cfg.searchQuery != "" && (len(cfg.repos) > 0 || cfg.repoFile != "") {
+		return errors.New("-searchquery cannot be combined with -repo or -repofile; add repo: or org: qualifiers to the query instead")
+	}
+	if cfg.searchQuery != "" && (cfg.collaborators || cfg.includeIssues) {
+		return errors.New("-searchquery cannot be combined with -includecollaborators or -includeissues, which need the repositories to scan")
+	}
+	if cfg.confirm && (!cfg.addToProject || cfg.dryRun) {
+		return errors.New("-confirm requires -addtoproject and cannot be used with -dryrun")
+	}
+	if cfg.pageSize < 1 || cfg.pageSize > publicprs.MaxPageSize {
+		return fmt.Errorf("invalid -pagesize %d: must be between 1 and %d", cfg.pageSize, publicprs.MaxPageSize)
+	}
+	if cfg.countOnly && (cfg.addToProject || cfg.prune || cfg.format != "text" || cfg.groupBy != "") {
+		return errors.New("-countonly cannot be used with -addtoproject, -prune, -format or -groupby")
+	}
+	if cfg.addConcurrency < 1 || cfg.addConcurrency > maxAddConcurrency {
+		return fmt.Errorf("invalid -addconcurrency %d: must be between 1 and %d", cfg.addConcurrency, maxAddConcurrency)
+	}
+	if cfg.confirm && cfg.addConcurrency > 1 {
+		return errors.New("-confirm cannot be used with -addconcurrency above 1")
+	}
+	if cfg.setStatus != "" && !cfg.addToProject {
+		return errors.New("-setstatus requires -addtoproject")
+	}
+	if cfg.pruneStatus != "" && !cfg.prune {
+		return errors.New("-prunestatus requires -prune")
+	}
+	if cfg.addedFile != "" && !cfg.addToProject {
+		return errors.New("-addedfile requires -addtoproject")
+	}
+	if cfg.addLabel != "" && !cfg.addToProject {
+		return errors.New("-addlabel requires -addtoproject")
+	}
+	var commentTemplate *template.Template
+	if cfg.comment != "" {
+		if !cfg.addToProject || cfg.anonymize {
+			return errors.New("-comment requires -addtoproject and cannot be used with -anonymize")
+		}
+		tmpl, err := parseCommentTemplate(cfg.comment)
+		if err != nil {
+			return err
+		}
+		commentTemplate = tmpl
+	}
+	var reviewers *reviewerRotation
+	if len(cfg.reviewers) > 0 || cfg.reviewersFile != "" {
+		if !cfg.addToProject || cfg.anonymize {
+			return errors.New("-requestreviewers and -reviewersfile require -addtoproject and cannot be used with -anonymize")
+		}
+		logins := cfg.reviewers
+		if cfg.reviewersFile != "" {
+			fileLogins, err := readListFile(cfg.reviewersFile)
+			if err != nil {
+				return err
 			}
+			logins = append(slices.Clone(logins), fileLogins...)
 		}
-	`)
-	req.Var("org", org)
-	req.Var("projectNumber", projectNumber)
+		if len(logins) == 0 {
+			return fmt.Errorf("-reviewersfile %s lists no reviewers", cfg.reviewersFile)
+		}
+		reviewers = newReviewerRotation(logins)
+	}
+	var projectNumbers []int
+	if cfg.addToProject || cfg.prune {
+		numbers, err := parseProjectNumbers(cfg.projects)
+		if err != nil {
+			return err
+		}
+		projectNumbers = numbers
+	}
+	if cfg.maxLines > 0 && cfg.minLines > cfg.maxLines {
+		return fmt.Errorf("-minlines %d is larger than -maxlines %d", cfg.minLines, cfg.maxLines)
+	}
+	if cfg.by != "created" && cfg.by != "updated" {
+		return fmt.Errorf("invalid -by %q: expected created or updated", cfg.by)
+	}
+	if cfg.warnAge < 0 {
+		return fmt.Errorf("invalid -warnage %s: must not be negative", cfg.warnAge)
+	}
+	if cfg.since < 0 || cfg.minAge < 0 {
+		return errors.New("-since and -minage must not be negative")
+	}
+	if cfg.since > 0 && cfg.minAge >= cfg.since {
+		return fmt.Errorf("-minage %s must be shorter than -since %s, or no PR can match", cfg.minAge, cfg.since)
+	}
+	if cfg.httpTimeout <= 0 {
+		return fmt.Errorf("invalid -httptimeout %s: expected a positive duration", cfg.httpTimeout)
+	}
+	if cfg.idleConns < 1 || cfg.idleTimeout <= 0 {
+		return errors.New("-idleconns must be at least 1 and -idletimeout must be positive")
+	}
+	if cfg.newOnly && cfg.stateFile == "" {
+		return errors.New("-newonly requires -statefile")
+	}
+	for _, association := range cfg.associations {
+		if !slices.Contains(publicprs.AuthorAssociations, association) {
+			return fmt.Errorf("invalid -association %q: expected one of %s", association, strings.Join(publicprs.AuthorAssociations, ", "))
+		}
+	}
+	if cfg.excludeDrafts && cfg.onlyDrafts {
+		return errors.New("-excludedrafts and -onlydrafts cannot be used together")
+	}
+	rateLimitStrategy := publicprs.RateLimitStrategy(cfg.rateLimitMode)
+	if rateLimitStrategy != publicprs.RateLimitSleep && rateLimitStrategy != publicprs.RateLimitAbort {
+		return fmt.Errorf("invalid -ratelimitstrategy %q: expected sleep or abort", cfg.rateLimitMode)
+	}
+	// The state and sort key are validated before any API call, and passed to NewOptions below
+	if _, err := publicprs.PullRequestStates(cfg.state); err != nil {
+		return err
+	}
+	// -by updated moves the date sort to the update time as well
+	sortKey := cfg.sortKey
+	if cfg.by == "updated" {
+		sortKey = strings.Replace(sortKey, "created", "updated", 1)
+	}
+	_, err := publicprs.PullRequestOrder(sortKey)
+	if err != nil {
+		return err
+	}
+	var authorPattern *regexp.Regexp
+	if cfg.authorPattern != "" {
+		authorPattern, err = regexp.Compile(cfg.authorPattern)
+		if err != nil {
+			return fmt.Errorf("invalid -authorpattern: %w", err)
+		}
+	}
 
-	var resp struct {
-		Organization struct {
-			ProjectV2 struct {
-				ID string `json:"id"`
-			} `json:"projectV2"`
-		} `json:"organization"`
+	if cfg.repoFile != "" {
+		if cfg.repos, err = readRepoFile(cfg.repoFile); err != nil {
+			return err
+		}
 	}
 
-	if err := client.Run(ctx, req, &resp); err != nil {
-		return "", fmt.Errorf("error fetching project ID: %w", err)
+	graphqlURL, restURL, err := publicprs.Endpoints(cfg.baseURL)
+	if err != nil {
+		return err
 	}
 
-	return resp.Organization.ProjectV2.ID, nil
-}
+	// Open the report file up front so an unwritable path fails before any API calls
+	out, err := openReport(cfg.outFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	// The text report lists every PR unless -quiet asks for the summary alone.  Project changes
+	// are part of the listing; with other formats they go to stderr.
+	listing := cfg.format == "text" && !cfg.quiet
+	// PR numbers are prefixed with their repository when they can come from several of them
+	multiRepo := len(cfg.repos) > 1 || cfg.searchQuery != ""
+	listingTemplate, err := parseListingTemplate(cfg.template, newPalette(cfg))
+	if err != nil {
+		return err
+	}
+	var changes io.Writer = out
+	switch {
+	case cfg.format != "text":
+		changes = os.Stderr
+	case cfg.quiet:
+		changes = io.Discard
+	}
 
-// addPRToProject fetches the global ID of the PR and adds it to the specified project using the global ID
-func addPRToProject(ctx context.Context, client *graphql.Client, projectID string, owner string, repo string, prNumber int) (bool, error) {
-	// Fetch the global ID of the PR
-	prID, err := getPullRequestID(ctx, client, owner, repo, prNumber)
+	// Every client shares the base transport, so proxies and -cacert apply to all of them, and
+	// connections are reused across them
+	baseTransport, err := newBaseTransport(cfg.caCert, cfg.idleConns, cfg.idleTimeout)
 	if err != nil {
-		return false, fmt.Errorf("error fetching global ID for PR #%d: %w", prNumber, err)
+		return err
 	}
 
-	// Check if the PR is already in the project
-	isInProject, err := checkPRInProject(ctx, client, projectID, prID)
+	restClient := newRESTClient(cfg, baseTransport)
+	token, err := resolveToken(ctx, cfg, restClient, restURL)
+	if err != nil {
+		return err
+	}
+	httpClient, err := newAPIClient(ctx, cfg, baseTransport, token)
 	if err != nil {
-		return false, fmt.Errorf("error checking PR in project: %w", err)
+		return err
+	}
+	client := publicprs.NewClient(graphqlURL, restURL, httpClient)
+	client.SetRateLimit(cfg.rateLimitMin, rateLimitStrategy)
+	if err := client.SetPageSize(cfg.pageSize); err != nil {
+		return err
 	}
 
-	if isInProject {
-		return false, nil
+	if cfg.preflight {
+		err := runPreflight(ctx, client, cfg, projectNumbers, out)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		return err
 	}
 
-	// Add PR to the project using the fetched PR global ID
-	req := graphql.NewRequest(`
-		mutation($projectID: ID!, $prID: ID!) {
-			addProjectV2ItemById(input: {projectId: $projectID, contentId: $prID}) {
-				item {
-					id
-				}
-			}
+	if err := checkTokenScopes(ctx, client, cfg); err != nil {
+		return err
+	}
+
+	if cfg.dumpMembers != "" {
+		err := dumpMembers(ctx, client, cfg, cfg.dumpMembers)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
 		}
-	`)
+		return err
+	}
 
-	req.Var("projectID", projectID)
-	req.Var("prID", prID)
+	// Get the project global IDs, only when the projects are used so read-only runs don't need
+	// project access
+	var projects []*project
+	if cfg.addToProject || cfg.prune {
+		projects, err = resolveProjects(ctx, client, cfg.projectOwner, projectNumbers, cfg.refreshProject)
+		if err != nil {
+			return err
+		}
+	}
 
-	var mutationResp struct {
-		AddProjectV2ItemById struct {
-			Item struct {
-				ID string `json:"id"`
-			} `json:"item"`
-		} `json:"addProjectV2ItemById"`
+	// Fetch organization members
+	members, err := fetchMembers(ctx, client, cfg)
+	if err != nil {
+		return err
 	}
 
-	if err := client.Run(ctx, req, &mutationResp); err != nil {
-		return false, fmt.Errorf("error adding PR to project: %w", err)
+	// Fetch pull requests
+	var progress publicprs.ProgressFunc
+	if cfg.verbose {
+		progress = func(fetched, total int) {
+			slog.Debug("Fetching PRs", "fetched", fetched, "total", total)
+		}
+	}
+	window := publicprs.WithCreatedBetween(timeAgo(start, cfg.since), timeAgo(start, cfg.minAge))
+	if cfg.by == "updated" {
+		window = publicprs.WithUpdatedBetween(timeAgo(start, cfg.since), timeAgo(start, cfg.minAge))
+	}
+	options := []publicprs.Option{
+		publicprs.WithState(cfg.state),
+		publicprs.WithMaxPRs(cfg.maxPRs),
+		publicprs.WithMembers(members),
+		publicprs.WithBotsToExclude(cfg.botsToExclude...),
+		publicprs.WithLabels(cfg.requireAll, cfg.labels...),
+		publicprs.WithBaseBranches(cfg.baseBranches...),
+		publicprs.WithPathPrefixes(cfg.pathPrefixes...),
+		publicprs.WithMilestone(cfg.milestone),
+		publicprs.WithLines(cfg.minLines, cfg.maxLines),
+		publicprs.WithAuthorPattern(authorPattern),
+		publicprs.WithAssociations(cfg.associations...),
+		publicprs.WithOrder(sortKey),
+		publicprs.WithProgress(progress),
+		window,
+	}
+	// Switches only add their option when set
+	for _, option := range []struct {
+		set    bool
+		option publicprs.Option
+	}{
+		{cfg.searchQuery != "", publicprs.WithSearchQuery(cfg.searchQuery)},
+		{cfg.includeBots, publicprs.WithIncludeBots()},
+		{cfg.includeGhost, publicprs.WithIncludeGhost()},
+		{cfg.includeBody, publicprs.WithIncludeBody()},
+		{cfg.excludeDrafts, publicprs.WithExcludeDrafts()},
+		{cfg.onlyDrafts, publicprs.WithOnlyDrafts()},
+		{cfg.needsReview, publicprs.WithNeedsReview()},
+		{cfg.unassigned, publicprs.WithUnassigned()},
+		{cfg.linkedOnly, publicprs.WithLinkedOnly()},
+		{cfg.fromForks, publicprs.WithFromForks()},
+		{cfg.greenOnly, publicprs.WithGreenOnly()},
+	} {
+		if option.set {
+			options = append(options, option.option)
+		}
+	}
+	opts, err := publicprs.NewOptions(cfg.owner, cfg.repos, options...)
+	if err != nil {
+		return err
+	}
+	pullRequests, stats, err := publicprs.FetchExternalPRs(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+	var issues []publicprs.Issue
+	if cfg.includeIssues {
+		if issues, err = publicprs.FetchExternalIssues(ctx, client, opts); err != nil {
+			return err
+		}
 	}
 
-	return true, nil
-}
+	// The repositories of a repository file are reported one after the other, in the file's order
+	if cfg.repoFile != "" {
+		sort.SliceStable(pullRequests, func(i, j int) bool {
+			return repoIndex(cfg, pullRequests[i]) < repoIndex(cfg, pullRequests[j])
+		})
+	}
 
-// getPullRequestID fetches the global ID for a given PR by its number
-func getPullRequestID(ctx context.Context, client *graphql.Client, owner string, repo string, prNumber int) (string, error) {
-	req := graphql.NewRequest(`
-		query($owner: String!, $repo: String!, $prNumber: Int!) {
-			repository(owner: $owner, name: $repo) {
-				pullRequest(number: $prNumber) {
-					id
-				}
+	// Compare with the PRs reported by earlier runs; the state file is only updated once this run
+	// has succeeded, so PRs are not lost if it fails
+	var seen map[string]bool
+	external := pullRequests
+	newPRs := 0
+	if cfg.stateFile != "" {
+		seen, _, err = loadPRSet(cfg.stateFile)
+		if err != nil {
+			return err
+		}
+		var unseen []publicprs.PullRequest
+		for _, pr := range pullRequests {
+			if !seen[prKey(pr)] {
+				unseen = append(unseen, pr)
 			}
 		}
-	`)
+		newPRs = len(unseen)
+		if cfg.newOnly {
+			pullRequests = unseen
+		}
+	}
+	if cfg.countOnly {
+		fmt.Fprintln(out, len(pullRequests))
+		if err := out.Close(); err != nil {
+			return err
+		}
+		if cfg.failOnMatch && len(pullRequests) > 0 {
+			return errExternalPRsFound
+		}
+		return nil
+	}
+	if len(cfg.annotateOrgs) > 0 {
+		if pullRequests, err = annotateAuthorOrgs(ctx, client, cfg.annotateOrgs, pullRequests); err != nil {
+			return err
+		}
+	}
+	if cfg.anonymize {
+		pullRequests = anonymizeAuthors(pullRequests)
+		issues = anonymizeIssueAuthors(issues)
+	}
+	if cfg.warnAge > 0 {
+		pullRequests = markStale(pullRequests, start.Add(-cfg.warnAge))
+	}
 
-	req.Var("owner", owner)
-	req.Var("repo", repo)
-	req.Var("prNumber", prNumber)
+	// Resolve the status fields and options up front so a typo fails before anything is changed
+	if cfg.addToProject && cfg.setStatus != "" {
+		fieldName, optionName, ok := strings.Cut(cfg.setStatus, "=")
+		if !ok || fieldName == "" || optionName == "" {
+			return fmt.Errorf("invalid -setstatus %q: expected field=option", cfg.setStatus)
+		}
+		for _, p := range projects {
+			p.statusFieldID, p.statusOptionID, err = publicprs.GetSingleSelectOption(ctx, client, p.id, fieldName, optionName)
+			if err != nil {
+				return fmt.Errorf("project %d: %w", p.number, err)
+			}
+		}
+	}
+	if cfg.prune && cfg.pruneStatus != "" {
+		fieldName, optionName, ok := strings.Cut(cfg.pruneStatus, "=")
+		if !ok || fieldName == "" || optionName == "" {
+			return fmt.Errorf("invalid -prunestatus %q: expected field=option", cfg.pruneStatus)
+		}
+		for _, p := range projects {
+			if _, _, err := publicprs.GetSingleSelectOption(ctx, client, p.id, fieldName, optionName); err != nil {
+				return fmt.Errorf("project %d: %w", p.number, err)
+			}
+		}
+	}
+	var labeler *prLabeler
+	if cfg.addToProject && cfg.addLabel != "" {
+		labeler = newPRLabeler(cfg.addLabel)
+		for _, repo := range cfg.repos {
+			owner, name := publicprs.SplitRepo(cfg.owner, repo)
+			if _, err := labeler.labelID(ctx, client, owner, name); err != nil {
+				return fmt.Errorf("invalid -addlabel: %w", err)
+			}
+		}
+	}
 
-	var resp struct {
-		Repository struct {
-			PullRequest struct {
-				ID string `json:"id"`
-			} `json:"pullRequest"`
-		} `json:"repository"`
+	// Load each project's items once; they are shared by the add and prune steps so each PR or issue
+	// can be checked without another query
+	for _, p := range projects {
+		items, err := publicprs.ProjectItems(ctx, client, p.id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch items of project %d: %w", p.number, err)
+		}
+		p.items = publicprs.PRItems(items)
+		p.inProject = publicprs.ContentIDs(items)
 	}
 
-	if err := client.Run(ctx, req, &resp); err != nil {
-		return "", fmt.Errorf("error fetching PR ID: %w", err)
+	if listing {
+		fmt.Fprintf(out, "PRs created by users outside of %s:\n", slices.Concat(cfg.orgs, cfg.teams))
+		fmt.Fprintf(out, "-------------------------------------------")
+	}
+	var confirm *confirmer
+	if cfg.confirm {
+		confirm = newConfirmer(os.Stdin, os.Stderr)
 	}
+	// PRs are added one at a time, in report order, unless -addconcurrency allows more
+	var (
+		added atomic.Int64
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, cfg.addConcurrency)
+	)
+	// Only additions that changed a project are written to -addedfile
+	var additions addedLog
+	dispatch := func(add func()) {
+		if cfg.addConcurrency == 1 {
+			add()
+			return
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			add()
+		}()
+	}
+	for i, pr := range pullRequests {
+		if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return err
+		}
+		if listing && cfg.groupBy == "" && cfg.repoFile != "" && (i == 0 || pr.NameWithOwner() != pullRequests[i-1].NameWithOwner()) {
+			fmt.Fprintf(out, "\n=== %s ===\n", pr.NameWithOwner())
+		}
+		if listing && cfg.groupBy == "" {
+			if err := listingTemplate.Execute(out, pr); err != nil {
+				slog.Error("Error rendering PR", "pr", pr.Number, "err", err)
+			}
+			// Custom templates pick the details they show themselves
+			if len(cfg.annotateOrgs) > 0 && cfg.template == "" {
+				fmt.Fprintf(out, "Author orgs: %s\n", loginList(pr.AuthorOrgs))
+			}
+			if cfg.includeBody && cfg.template == "" {
+				fmt.Fprintf(out, "Body: %s\n", bodySummary(pr.Body))
+			}
+		}
 
-	return resp.Repository.PullRequest.ID, nil
-}
+		if cfg.addToProject {
+			dispatch(func() {
+				newlyAdded := false
+				for _, p := range projects {
+					if addToProject(ctx, client, cfg, changes, p, pr, confirm) {
+						added.Add(1)
+						newlyAdded = true
+						if !cfg.dryRun {
+							additions.add(addedItem{Type: "pr", Repo: pr.NameWithOwner(), Number: pr.Number, URL: pr.URL, Project: p.number})
+						}
+					}
+				}
+				// Comment once, however many projects the PR was added to
+				if newlyAdded && commentTemplate != nil {
+					commentOnPR(ctx, client, cfg, changes, commentTemplate, pr)
+				}
+				if newlyAdded && reviewers != nil {
+					reviewers.request(ctx, client, cfg, changes, pr)
+				}
+				if newlyAdded && labeler != nil {
+					labeler.apply(ctx, client, cfg, changes, pr)
+				}
+			})
+		}
+	}
 
-// fetchOrgMembers fetches all members from a GitHub organization using the REST API
-// This is using the REST API instead of graphql because we need ALL org members and MembersWithRole
-// doesn't give us the full list that we need.
-func fetchOrgMembers(ctx context.Context, token, org string, members map[string]bool) error {
-	client := &http.Client{
-		Timeout: time.Second * 15,
+	groups := groupByAuthor(pullRequests)
+	if listing && cfg.groupBy == "author" {
+		printAuthorGroups(out, groups, multiRepo)
+	}
+	if listing && len(cfg.annotateOrgs) > 0 {
+		printOrgCounts(out, cfg.annotateOrgs, pullRequests)
 	}
 
-	perPage := 100
-	page := 1
+	if listing && cfg.includeIssues {
+		fmt.Fprintf(out, "\nIssues created by users outside of %s:\n", slices.Concat(cfg.orgs, cfg.teams))
+		fmt.Fprintf(out, "-------------------------------------------")
+	}
+	for _, issue := range issues {
+		if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return err
+		}
+		if listing {
+			fmt.Fprintf(out, "\nIssue #%d by %s\nRepo: %s\nTitle: %s\nLabels: %s\nLink: %s\n", issue.Number, issue.Author, issue.NameWithOwner(), issue.Title, loginList(issue.Labels), issue.URL)
+		}
+		if cfg.addToProject {
+			dispatch(func() {
+				for _, p := range projects {
+					if addIssueToProject(ctx, client, cfg, changes, p, issue, confirm) {
+						added.Add(1)
+						if !cfg.dryRun {
+							additions.add(addedItem{Type: "issue", Repo: issue.NameWithOwner(), Number: issue.Number, URL: issue.URL, Project: p.number})
+						}
+					}
+				}
+			})
+		}
+	}
+	wg.Wait()
 
-	for {
-		req, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/orgs/%s/members?per_page=%d&page=%d", org, perPage, page), nil)
-		if err != nil {
-			return fmt.Errorf("error creating request: %v", err)
+	removed := 0
+	if cfg.prune {
+		fmt.Fprintln(changes)
+		for _, p := range projects {
+			n, err := pruneProject(ctx, client, cfg, changes, p, members)
+			removed += n
+			if err != nil {
+				return err
+			}
 		}
+	}
 
-		req.Header.Set("Authorization", "token "+token)
+	summary := reportSummary{
+		Scanned:  stats.Scanned,
+		External: stats.External,
+		Issues:   len(issues),
+		Authors:  len(groups),
+		Bots:     stats.Bots,
+		Added:    int(added.Load()),
+		Removed:  removed,
+		New:      newPRs,
+		DryRun:   cfg.dryRun,
+	}
+	switch cfg.format {
+	case "json":
+		report := jsonReport{PullRequests: pullRequests, Issues: issues, Summary: summary}
+		if cfg.groupBy == "author" {
+			report.Authors = authorSummaries(groups)
+		}
+		writeJSONReport(out, report)
+	case "markdown":
+		writeMarkdownReport(out, pullRequests, multiRepo)
+		if cfg.includeIssues {
+			fmt.Fprintln(out)
+			writeMarkdownIssues(out, issues, multiRepo)
+		}
+		fmt.Fprintln(out)
+		printSummary(out, cfg, summary)
+	default:
+		if listing {
+			fmt.Fprintln(out)
+		}
+		printSummary(out, cfg, summary)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
 
-		//log.Printf("Making call to fetch 100 members for %s", org)
-		resp, err := client.Do(req)
+	if cfg.slackWebhook != "" {
+		statePath := cfg.slackState
+		if statePath == "" {
+			if statePath, err = defaultSlackStatePath(); err != nil {
+				return fmt.Errorf("error locating Slack state file: %w", err)
+			}
+		}
+		posted, err := notifySlack(ctx, restClient, cfg.slackWebhook, statePath, pullRequests)
 		if err != nil {
-			return fmt.Errorf("error making request: %v", err)
+			return err
 		}
-		defer resp.Body.Close()
+		slog.Info("Posted new PRs to Slack", "count", posted)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("error: received non-OK response %d", resp.StatusCode)
+	if cfg.metricsFile != "" {
+		metrics := runMetrics{
+			Owner:    cfg.owner,
+			Scanned:  stats.Scanned,
+			External: stats.External,
+			Bots:     stats.Bots,
+			Duration: time.Since(start),
 		}
-
-		var orgMembers []Member
-		if err := json.NewDecoder(resp.Body).Decode(&orgMembers); err != nil {
-			return fmt.Errorf("error decoding response: %v", err)
+		if !cfg.dryRun {
+			metrics.Added = int(added.Load())
+			metrics.Removed = removed
+		}
+		if err := writeMetricsFile(cfg.metricsFile, metrics); err != nil {
+			return err
 		}
+	}
 
-		for _, member := range orgMembers {
-			members[member.Login] = true
+	if cfg.stateFile != "" {
+		for _, pr := range external {
+			seen[prKey(pr)] = true
+		}
+		if err := savePRSet(cfg.stateFile, seen); err != nil {
+			return err
 		}
+	}
 
-		if len(orgMembers) < perPage {
-			break
+	if cfg.addedFile != "" {
+		if err := writeAddedFile(cfg.addedFile, &additions); err != nil {
+			return err
 		}
-		page++
 	}
 
+	if cfg.failOnMatch && len(pullRequests) > 0 {
+		return errExternalPRsFound
+	}
 	return nil
 }
 
-// checkPRInProject checks if a pull request is already in the specified project.
-func checkPRInProject(ctx context.Context, client *graphql.Client, projectID, prID string) (bool, error) {
-	req := graphql.NewRequest(`
-		query($projectID: ID!) {
-			node(id: $projectID) {
-				... on ProjectV2 {
-					items(first: 100) {
-						nodes {
-							id
-							content {
-								... on PullRequest {
-									id
-								}
-							}
-						}
-					}
-				}
-			}
+// isFlagSet reports whether the named flag was passed on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
-	`)
-
-	req.Var("projectID", projectID)
+	})
+	return set
+}
 
-	var resp struct {
-		Node struct {
-			Items struct {
-				Nodes []struct {
-					ID      string
-					Content struct {
-						ID string
-					}
-				}
-			}
-		}
+// timeAgo returns the time d before now, or the zero time, meaning no bound, when d is zero.
+func timeAgo(now time.Time, d time.Duration) time.Time {
+	if d == 0 {
+		return time.Time{}
 	}
+	return now.Add(-d)
+}
 
-	if err := client.Run(ctx, req, &resp); err != nil {
-		return false, fmt.Errorf("error checking PR in project: %w", err)
+// readRepoFile reads the repositories listed in a -repofile, one owner/repo per line.  Malformed
+// lines are skipped with a warning.
+func readRepoFile(path string) ([]string, error) {
+	entries, err := readListFile(path)
+	if err != nil {
+		return nil, err
 	}
-
-	for _, item := range resp.Node.Items.Nodes {
-		if item.Content.ID == prID {
-			return true, nil
+	var repos []string
+	for _, entry := range entries {
+		if owner, name, ok := strings.Cut(entry, "/"); !ok || owner == "" || name == "" || strings.ContainsAny(name, "/ ") {
+			slog.Warn("Skipping malformed repository, expected owner/repo", "file", path, "repo", entry)
+			continue
 		}
+		repos = append(repos, entry)
 	}
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("no repositories listed in %s", path)
+	}
+	return repos, nil
+}
+
+// repoIndex returns the position of the repository of pr in cfg.repos.
+func repoIndex(cfg config, pr publicprs.PullRequest) int {
+	return slices.IndexFunc(cfg.repos, func(repo string) bool {
+		owner, name := publicprs.SplitRepo(cfg.owner, repo)
+		return owner == pr.Owner && name == pr.Repo
+	})
+}
 
-	return false, nil
+// splitList splits a comma-separated flag value, returning nil for an empty value.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"publicprs/pkg/publicprs"
+)
+
+func TestReadRepoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repos.txt")
+	data := "# UI\nrancher/dashboard\nrancher\n\nrancher/rancher/extra\nSUSE/elemental\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readRepoFile(path)
+	if err != nil {
+		t.Fatalf("readRepoFile() error = %v", err)
+	}
+	if want := []string{"rancher/dashboard", "SUSE/elemental"}; !slices.Equal(got, want) {
+		t.Errorf("readRepoFile() = %q, want %q", got, want)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: errors.New("invalid -state"), want: 1},
+		{err: errExternalPRsFound, want: 2},
+		{err: fmt.Errorf("error fetching members: %w", publicprs.ErrTokenMissing), want: 3},
+		{err: fmt.Errorf("error fetching PRs: %w", publicprs.ErrRateLimited), want: 4},
+		{err: fmt.Errorf("%w: none of the projects could be resolved", publicprs.ErrProjectNotFound), want: 5},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
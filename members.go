@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"publicprs/pkg/publicprs"
+)
+
+// fetchMembers collects the members of every configured organization and team, fetching up to
+// cfg.concurrency of them at a time.  The first failure cancels the remaining fetches.
+// With cfg.collaborators, the collaborators of the scanned repositories are added as well, and so
+// are the logins listed in the cfg.extraMembers file.
+func fetchMembers(ctx context.Context, client *publicprs.Client, cfg config) (map[string]bool, error) {
+	sources, err := fetchMemberSources(ctx, client, cfg)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]bool, len(sources))
+	for login := range sources {
+		members[login] = true
+	}
+	return members, nil
+}
+
+// fetchMemberSources collects the members like fetchMembers, and returns the sources each login was
+// found in, such as "org rancher" or "team rancher/core", sorted.
+func fetchMemberSources(ctx context.Context, client *publicprs.Client, cfg config) (map[string][]string, error) {
+	// Read the extra members first so a bad path fails before any API calls
+	var extraMembers []string
+	if cfg.extraMembers != "" {
+		var err error
+		if extraMembers, err = readListFile(cfg.extraMembers); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if len(cfg.orgs) == 0 && len(cfg.teams) == 0 && !cfg.collaborators && cfg.extraMembers == "" {
+		slog.Warn("No organizations, teams or extra members configured, every author is reported as external")
+	}
+	slog.Info("Members of these sources are internal", "orgs", cfg.orgs, "teams", cfg.teams, "collaborators", cfg.collaborators, "extramembers", cfg.extraMembers)
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		members  = make(map[string][]string)
+		sem      = make(chan struct{}, max(cfg.concurrency, 1))
+	)
+
+	for _, source := range slices.Concat(cfg.orgs, cfg.teams) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			sourceMembers, err := fetchSourceMembers(ctx, client, cfg, source)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("error fetching members from %s: %w", describeSource(source), err)
+					cancel()
+				}
+				return
+			}
+			// Logins can belong to several sources, so the new ones are counted apart from the total
+			added := 0
+			for login := range sourceMembers {
+				if len(members[login]) == 0 {
+					added++
+				}
+				members[login] = append(members[login], describeSource(source))
+			}
+			slog.Info("Fetched members", "source", describeSource(source), "members", len(sourceMembers), "new", added, "total", len(members))
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Which logins are new depends on the order the fetches complete in, so also log the logins
+	// only found in each source
+	if sources := slices.Concat(cfg.orgs, cfg.teams); len(sources) > 1 {
+		for _, source := range sources {
+			slog.Info("Members only found in source", "source", describeSource(source), "unique", uniqueMembers(members, describeSource(source)))
+		}
+	}
+
+	if cfg.collaborators {
+		for _, repo := range cfg.repos {
+			owner, name := publicprs.SplitRepo(cfg.owner, repo)
+			collaborators := make(map[string]bool)
+			if err := publicprs.FetchCollaborators(ctx, client, owner, name, cfg.affiliation, collaborators); err != nil {
+				return nil, fmt.Errorf("error fetching collaborators of %s/%s: %w", owner, name, err)
+			}
+			added := 0
+			for login := range collaborators {
+				if len(members[login]) == 0 {
+					added++
+				}
+				members[login] = append(members[login], "collaborators of "+owner+"/"+name)
+			}
+			slog.Info("Fetched collaborators", "repo", owner+"/"+name, "affiliation", cfg.affiliation, "collaborators", len(collaborators), "new", added, "total", len(members))
+		}
+	}
+
+	added := 0
+	for _, login := range extraMembers {
+		if len(members[login]) == 0 {
+			added++
+		}
+		members[login] = append(members[login], "file "+cfg.extraMembers)
+	}
+	if len(extraMembers) > 0 {
+		slog.Info("Added extra members", "file", cfg.extraMembers, "count", len(extraMembers), "new", added, "total", len(members))
+	}
+	for login, sources := range members {
+		slices.Sort(sources)
+		members[login] = slices.Compact(sources)
+	}
+	return members, nil
+}
+
+// uniqueMembers returns how many of the logins collected by fetchMemberSources were found in source,
+// as named by describeSource, and in no other source.
+func uniqueMembers(members map[string][]string, source string) int {
+	count := 0
+	for _, sources := range members {
+		if len(sources) == 1 && sources[0] == source {
+			count++
+		}
+	}
+	return count
+}
+
+// memberSource is a login and the sources it was found in, as written by -dumpmembers.
+type memberSource struct {
+	Login   string   `json:"login"`
+	Sources []string `json:"sources"`
+}
+
+// writeMemberSources writes the logins of sources, sorted, with the sources each was found in as a
+// JSON list.
+func writeMemberSources(w io.Writer, sources map[string][]string) error {
+	list := make([]memberSource, 0, len(sources))
+	for login, loginSources := range sources {
+		list = append(list, memberSource{Login: login, Sources: loginSources})
+	}
+	slices.SortFunc(list, func(a, b memberSource) int { return strings.Compare(a.Login, b.Login) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// dumpMembers fetches the members and writes them with writeMemberSources to stderr when path is
+// "-", or to the file at path, for -dumpmembers.
+func dumpMembers(ctx context.Context, client *publicprs.Client, cfg config, path string) error {
+	sources, err := fetchMemberSources(ctx, client, cfg)
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		return writeMemberSources(os.Stderr, sources)
+	}
+	var b bytes.Buffer
+	if err := writeMemberSources(&b, sources); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, b.Bytes()); err != nil {
+		return fmt.Errorf("error writing members file: %w", err)
+	}
+	slog.Info("Wrote members", "path", path, "count", len(sources))
+	return nil
+}
+
+// internalOrgs returns the organizations of orgs whose members are internal: all of them except the
+// ones in external, compared case-insensitively.  Only these organizations, the -teams, and with
+// -includecollaborators and -extramembers the collaborators and listed logins, make authors
+// internal; the -owner and -projectowner organizations don't, unless they are listed in -orgs.
+func internalOrgs(orgs, external []string) []string {
+	return slices.DeleteFunc(slices.Clone(orgs), func(org string) bool {
+		return slices.ContainsFunc(external, func(e string) bool { return strings.EqualFold(e, org) })
+	})
+}
+
+// annotateAuthorOrgs returns a copy of pullRequests with AuthorOrgs set to the partner organizations
+// that list each author as a public member.  Each author's organizations are fetched once per run;
+// lookups that fail are logged and leave the author's PRs unannotated.
+func annotateAuthorOrgs(ctx context.Context, client *publicprs.Client, partners []string, pullRequests []publicprs.PullRequest) ([]publicprs.PullRequest, error) {
+	cache := make(map[string][]string)
+	annotated := make([]publicprs.PullRequest, 0, len(pullRequests))
+	for _, pr := range pullRequests {
+		orgs, cached := cache[pr.Author]
+		if !cached && pr.Author != publicprs.DeletedUser {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			userOrgs := make(map[string]bool)
+			if err := publicprs.FetchUserOrgs(ctx, client, pr.Author, userOrgs); err != nil {
+				slog.Warn("Unable to fetch organizations of author", "author", pr.Author, "err", err)
+			}
+			for _, partner := range partners {
+				for org := range userOrgs {
+					if strings.EqualFold(org, partner) {
+						orgs = append(orgs, partner)
+						break
+					}
+				}
+			}
+			cache[pr.Author] = orgs
+		}
+		pr.AuthorOrgs = orgs
+		annotated = append(annotated, pr)
+	}
+	slog.Info("Fetched organizations of authors", "authors", len(cache))
+	return annotated, nil
+}
+
+// readListFile reads a file listing one entry, such as a GitHub login, per line.  Surrounding
+// whitespace is trimmed, and blank lines and lines starting with # are ignored.
+func readListFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	var logins []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		logins = append(logins, line)
+	}
+	return logins, nil
+}
+
+// fetchSourceMembers returns the members of a single organization, or of a team when source has the
+// form org/team-slug, using the on-disk cache when possible.
+func fetchSourceMembers(ctx context.Context, client *publicprs.Client, cfg config, source string) (map[string]bool, error) {
+	if sourceMembers, cached := loadCachedMembers(client.Host(), source, cfg.memberCacheTTL, cfg.refreshMembers); cached {
+		return sourceMembers, nil
+	}
+
+	sourceMembers := make(map[string]bool)
+	var err error
+	if org, team, isTeam := strings.Cut(source, "/"); isTeam {
+		err = publicprs.FetchTeamMembers(ctx, client, org, team, sourceMembers)
+	} else {
+		err = publicprs.FetchOrgMembers(ctx, client, source, sourceMembers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(source, "/") {
+		checkOrgMemberCount(ctx, client, source, len(sourceMembers))
+	}
+	if cfg.memberCacheTTL > 0 {
+		if err := saveCachedMembers(client.Host(), source, sourceMembers); err != nil {
+			slog.Warn("Unable to cache members", "source", describeSource(source), "err", err)
+		}
+	}
+	return sourceMembers, nil
+}
+
+// checkOrgMemberCount warns when fewer members were fetched from org than GitHub counts.  The
+// members endpoint silently returns only the public members when the token cannot see private
+// memberships, which fine-grained tokens sometimes can't even with the Members permission, and
+// every private member would then be reported as external.
+func checkOrgMemberCount(ctx context.Context, client *publicprs.Client, org string, fetched int) {
+	total, err := publicprs.OrgMemberCount(ctx, client, org)
+	if err != nil {
+		slog.Debug("Unable to check the member count", "org", org, "err", err)
+		return
+	}
+	if fetched < total {
+		slog.Warn("Only part of the organization's members could be fetched, members keeping their membership private will be reported as external. "+
+			"The token probably cannot see private memberships; use a classic token with read:org, or grant a fine-grained token read access to the organization's Members",
+			"org", org, "fetched", fetched, "members", total, "hidden", total-fetched)
+	}
+}
+
+// describeSource names a membership source for log and error messages.
+func describeSource(source string) string {
+	if strings.Contains(source, "/") {
+		return "team " + source
+	}
+	return "org " + source
+}
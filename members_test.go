@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "extra-members.txt")
+	data := "# contractors\njdoe\n\n  asmith \n#former-member\nci-service-account\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readListFile(path)
+	if err != nil {
+		t.Fatalf("readListFile() error = %v", err)
+	}
+	if want := []string{"jdoe", "asmith", "ci-service-account"}; !slices.Equal(got, want) {
+		t.Errorf("readListFile() = %q, want %q", got, want)
+	}
+
+	if _, err := readListFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("readListFile() of a missing file should fail")
+	}
+}
+
+func TestInternalOrgs(t *testing.T) {
+	orgs := []string{"rancher", "SUSE", "partner"}
+	if got, want := internalOrgs(orgs, []string{"suse", "unknown"}), []string{"rancher", "partner"}; !slices.Equal(got, want) {
+		t.Errorf("internalOrgs() = %q, want %q", got, want)
+	}
+	if got := internalOrgs(orgs, nil); !slices.Equal(got, orgs) {
+		t.Errorf("internalOrgs() without external orgs = %q, want %q", got, orgs)
+	}
+	if len(orgs) != 3 || orgs[1] != "SUSE" {
+		t.Errorf("internalOrgs() modified its argument: %q", orgs)
+	}
+}
+
+func TestWriteMemberSources(t *testing.T) {
+	sources := map[string][]string{
+		"jdoe":   {"org SUSE", "org rancher"},
+		"asmith": {"team rancher/core"},
+	}
+	var b strings.Builder
+	if err := writeMemberSources(&b, sources); err != nil {
+		t.Fatalf("writeMemberSources() error = %v", err)
+	}
+	var got []memberSource
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("error parsing members: %v", err)
+	}
+	want := []memberSource{
+		{Login: "asmith", Sources: []string{"team rancher/core"}},
+		{Login: "jdoe", Sources: []string{"org SUSE", "org rancher"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("writeMemberSources() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUniqueMembers(t *testing.T) {
+	members := map[string][]string{
+		"jdoe":   {"org rancher", "org SUSE"},
+		"asmith": {"org rancher"},
+		"bking":  {"org SUSE"},
+		"cle":    {"org SUSE"},
+	}
+	if got := uniqueMembers(members, "org rancher"); got != 1 {
+		t.Errorf("uniqueMembers(rancher) = %d, want 1", got)
+	}
+	if got := uniqueMembers(members, "org SUSE"); got != 2 {
+		t.Errorf("uniqueMembers(SUSE) = %d, want 2", got)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// runMetrics are the figures of a run written to the -metricsfile.
+type runMetrics struct {
+	Owner    string
+	Scanned  int
+	External int
+	Bots     int
+	Added    int
+	Removed  int
+	Duration time.Duration
+}
+
+// writeMetrics writes m in the Prometheus text exposition format.
+func writeMetrics(w io.Writer, m runMetrics) error {
+	labels := fmt.Sprintf(`{owner=%q}`, m.Owner)
+	gauges := []struct {
+		name  string
+		help  string
+		value string
+	}{
+		{"publicprs_scanned_total", "PRs fetched from the scanned repositories.", fmt.Sprint(m.Scanned)},
+		{"publicprs_external_open_total", "PRs reported as opened by users outside of the organizations.", fmt.Sprint(m.External)},
+		{"publicprs_bots_skipped_total", "PRs from non-members skipped because they were authored by a bot.", fmt.Sprint(m.Bots)},
+		{"publicprs_added_total", "PRs added to the project.", fmt.Sprint(m.Added)},
+		{"publicprs_removed_total", "PRs removed from the project.", fmt.Sprint(m.Removed)},
+		{"publicprs_scan_duration_seconds", "Duration of the run in seconds.", fmt.Sprintf("%.3f", m.Duration.Seconds())},
+		{"publicprs_last_run_timestamp_seconds", "Unix time at which the run finished.", fmt.Sprint(time.Now().Unix())},
+	}
+
+	var b strings.Builder
+	for _, g := range gauges {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s%s %s\n", g.name, g.help, g.name, g.name, labels, g.value)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMetricsFile writes m to path.  The file is replaced atomically, so a textfile collector
+// never reads a partially written file.
+func writeMetricsFile(path string, m runMetrics) error {
+	var b bytes.Buffer
+	if err := writeMetrics(&b, m); err != nil {
+		return fmt.Errorf("error writing metrics file: %w", err)
+	}
+	if err := writeFileAtomic(path, b.Bytes()); err != nil {
+		return fmt.Errorf("error writing metrics file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "publicprs.prom")
+	m := runMetrics{Owner: "rancher", Scanned: 120, External: 7, Bots: 3, Added: 2, Duration: 1500 * time.Millisecond}
+	if err := writeMetricsFile(path, m); err != nil {
+		t.Fatalf("writeMetricsFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE publicprs_external_open_total gauge\n",
+		`publicprs_external_open_total{owner="rancher"} 7` + "\n",
+		`publicprs_added_total{owner="rancher"} 2` + "\n",
+		`publicprs_scan_duration_seconds{owner="rancher"} 1.500` + "\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("metrics file is missing %q:\n%s", want, data)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d files in the metrics directory, want only the metrics file", len(entries))
+	}
+}
@@ -0,0 +1,69 @@
+package publicprs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAppInstallationToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			t.Fatalf("Authorization = %q, want a bearer token", r.Header.Get("Authorization"))
+		}
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			t.Fatalf("malformed JWT %q", jwt)
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("JWT signature does not verify: %v", err)
+		}
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var decoded map[string]interface{}
+		json.Unmarshal(claims, &decoded)
+		if decoded["iss"] != "1234" {
+			t.Errorf("iss claim = %v, want 1234", decoded["iss"])
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"token": "installation-token"})
+	}))
+	defer server.Close()
+
+	token, err := AppInstallationToken(context.Background(), server.Client(), server.URL, 1234, 42, keyPEM)
+	if err != nil {
+		t.Fatalf("AppInstallationToken() error = %v", err)
+	}
+	if token != "installation-token" {
+		t.Errorf("AppInstallationToken() = %q", token)
+	}
+}
+
+func TestAppInstallationTokenInvalidKey(t *testing.T) {
+	if _, err := AppInstallationToken(context.Background(), http.DefaultClient, "http://unused", 1, 1, []byte("not a key")); err == nil {
+		t.Fatal("AppInstallationToken() expected an error for an invalid key")
+	}
+}
@@ -0,0 +1,261 @@
+package publicprs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/machinebox/graphql"
+)
+
+const (
+	// DefaultGraphQLURL is the GraphQL endpoint of public GitHub.
+	DefaultGraphQLURL = "https://api.github.com/graphql"
+	// DefaultRESTURL is the REST API base URL of public GitHub.
+	DefaultRESTURL = "https://api.github.com"
+)
+
+// Endpoints derives the GraphQL endpoint and REST base URL from a GitHub base URL.  Public GitHub
+// (github.com or api.github.com) maps to the default endpoints; any other host is treated as
+// GitHub Enterprise Server, which serves GraphQL under /api/graphql and REST under /api/v3.
+// An empty baseURL returns the public GitHub endpoints.
+func Endpoints(baseURL string) (graphqlURL, restURL string, err error) {
+	if baseURL == "" {
+		return DefaultGraphQLURL, DefaultRESTURL, nil
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid GitHub base URL %q: %w", baseURL, err)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", "", fmt.Errorf("invalid GitHub base URL %q: expected an http(s) URL such as https://github.example.com", baseURL)
+	}
+
+	if u.Host == "github.com" || u.Host == "api.github.com" {
+		return DefaultGraphQLURL, DefaultRESTURL, nil
+	}
+
+	// Accept the host alone as well as the REST or GraphQL API URL of the instance.
+	path := strings.TrimSuffix(u.Path, "/")
+	path = strings.TrimSuffix(path, "/api/v3")
+	path = strings.TrimSuffix(path, "/api/graphql")
+	root := u.Scheme + "://" + u.Host + path
+
+	return root + "/api/graphql", root + "/api/v3", nil
+}
+
+// RateLimitStrategy selects what a Client does when the remaining rate limit drops below its threshold.
+type RateLimitStrategy string
+
+const (
+	// RateLimitSleep waits for the rate limit to reset before continuing.
+	RateLimitSleep RateLimitStrategy = "sleep"
+	// RateLimitAbort fails the request that left too little of the rate limit.
+	RateLimitAbort RateLimitStrategy = "abort"
+)
+
+// Client holds the GitHub endpoints and the HTTP client used to talk to the GraphQL and REST APIs.
+type Client struct {
+	graphql *graphql.Client
+	rest    *http.Client
+	restURL string
+
+	rateLimitThreshold int
+	rateLimitStrategy  RateLimitStrategy
+	pageSize           int
+}
+
+// MaxPageSize is the largest page size GitHub accepts, and the default of a Client.
+const MaxPageSize = 100
+
+// rateLimit is the rate limit status GitHub returns for GraphQL queries selecting it.
+type rateLimit struct {
+	Remaining int
+	ResetAt   time.Time
+}
+
+// NewClient returns a Client sending GraphQL queries to graphqlURL and REST requests to restURL,
+// both through httpClient.  httpClient is expected to authenticate the requests, for example with
+// an oauth2 token source, so token handling, proxies and retries are the same for both APIs.
+// Rejected credentials and rate limits fail GraphQL queries with ErrTokenMissing and ErrRateLimited.
+func NewClient(graphqlURL, restURL string, httpClient *http.Client) *Client {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	graphqlHTTPClient := *httpClient
+	graphqlHTTPClient.Transport = &statusTransport{base: base}
+	return &Client{
+		graphql: graphql.NewClient(graphqlURL, graphql.WithHTTPClient(&graphqlHTTPClient)),
+		rest:    httpClient,
+		restURL: strings.TrimSuffix(restURL, "/"),
+	}
+}
+
+// SetPageSize sets how many PRs or members the client fetches per page, between 1 and MaxPageSize.
+// Smaller pages make each query cheaper, which can avoid GitHub timing out on heavy queries.
+func (c *Client) SetPageSize(size int) error {
+	if size < 1 || size > MaxPageSize {
+		return fmt.Errorf("invalid page size %d: must be between 1 and %d", size, MaxPageSize)
+	}
+	c.pageSize = size
+	return nil
+}
+
+// Host returns the host of the client's API endpoints, such as api.github.com or the host of a
+// GitHub Enterprise Server instance, for telling apart data cached from different servers.
+func (c *Client) Host() string {
+	u, err := url.Parse(c.restURL)
+	if err != nil || u.Host == "" {
+		return c.restURL
+	}
+	return strings.ToLower(u.Host)
+}
+
+// perPage returns the page size set by SetPageSize, or MaxPageSize when none was set.
+func (c *Client) perPage() int {
+	if c.pageSize == 0 {
+		return MaxPageSize
+	}
+	return c.pageSize
+}
+
+// SetRateLimit makes the client apply strategy whenever fewer than threshold requests (or GraphQL
+// points) remain in the current rate limit window.  A threshold of zero disables the check.
+func (c *Client) SetRateLimit(threshold int, strategy RateLimitStrategy) {
+	c.rateLimitThreshold = threshold
+	c.rateLimitStrategy = strategy
+}
+
+// checkRateLimit applies the client's rate limit strategy to the remaining budget of an API.
+func (c *Client) checkRateLimit(ctx context.Context, api string, remaining int, resetAt time.Time) error {
+	if c.rateLimitThreshold <= 0 || remaining >= c.rateLimitThreshold || resetAt.IsZero() {
+		return nil
+	}
+
+	if c.rateLimitStrategy == RateLimitAbort {
+		return fmt.Errorf("%w: %s rate limit nearly exhausted, %d remaining, resets at %s", ErrRateLimited, api, remaining, resetAt.Format(time.RFC3339))
+	}
+
+	wait := time.Until(resetAt)
+	if wait <= 0 {
+		return nil
+	}
+	slog.Warn("GitHub rate limit nearly exhausted, waiting for reset", "api", api, "remaining", remaining, "resetAt", resetAt, "wait", wait.Round(time.Second))
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// checkRESTRateLimit applies the client's rate limit strategy using the rate limit headers of a REST response.
+func (c *Client) checkRESTRateLimit(ctx context.Context, resp *http.Response) error {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return nil
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return c.checkRateLimit(ctx, "REST", remaining, time.Unix(reset, 0))
+}
+
+// run executes a GraphQL request and decodes its data into resp.  Any error GitHub returns fails the
+// request, even when it came with data.
+func (c *Client) run(ctx context.Context, req *graphql.Request, resp interface{}) error {
+	data, err := c.runRaw(ctx, req)
+	if !hasData(data) {
+		return err
+	}
+	if decodeErr := json.Unmarshal(data, resp); decodeErr != nil && err == nil {
+		err = fmt.Errorf("decoding response: %w", decodeErr)
+	}
+	return err
+}
+
+// runPartial executes a GraphQL request like run, but accepts partial results: when GitHub returns
+// errors alongside data, for example for a node the token cannot read, the error is logged as a
+// warning and the data is decoded into resp.  GitHub then returns null for the fields it could not
+// resolve, so callers must check the fields they rely on.  An error is only returned when no data
+// came back at all.
+func (c *Client) runPartial(ctx context.Context, req *graphql.Request, resp interface{}) error {
+	data, err := c.runRaw(ctx, req)
+	if !hasData(data) {
+		return err
+	}
+	if err != nil {
+		// The GraphQL client only reports the first of the errors
+		slog.Warn("GitHub returned partial results", "err", err)
+	}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// runRaw executes a GraphQL request and returns its undecoded data along with the first error
+// GitHub reported, logging the cost of the query at debug level.
+func (c *Client) runRaw(ctx context.Context, req *graphql.Request) (json.RawMessage, error) {
+	var data json.RawMessage
+	err := c.graphql.Run(ctx, req, &data)
+	// GitHub reports an exhausted GraphQL rate limit as an error of type RATE_LIMITED, which the
+	// GraphQL client only passes on by message
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "rate limit") {
+		err = fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	if hasData(data) && slog.Default().Enabled(ctx, slog.LevelDebug) {
+		logQueryCost(ctx, data)
+	}
+	return data, err
+}
+
+// hasData reports whether the data of a GraphQL response holds at least one non-null field.
+func hasData(data json.RawMessage) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+	for _, value := range fields {
+		if string(value) != "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// logQueryCost logs the rate limit cost of a GraphQL query whose response data selected
+// rateLimit { cost remaining }, naming the function that sent it.  Selecting rateLimit does
+// not itself cost any points.
+func logQueryCost(ctx context.Context, data json.RawMessage) {
+	var cost struct {
+		RateLimit *struct {
+			Cost      int
+			Remaining int
+		}
+	}
+	if err := json.Unmarshal(data, &cost); err != nil || cost.RateLimit == nil {
+		return
+	}
+
+	query := "unknown"
+	// Skip logQueryCost, runRaw and run or runPartial to find the function sending the query
+	if pc, _, _, ok := runtime.Caller(3); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			query = fn.Name()[strings.LastIndex(fn.Name(), "/")+1:]
+		}
+	}
+	slog.DebugContext(ctx, "GraphQL query cost", "query", query, "cost", cost.RateLimit.Cost, "remaining", cost.RateLimit.Remaining)
+}
@@ -0,0 +1,109 @@
+package publicprs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEndpoints(t *testing.T) {
+	tests := []struct {
+		baseURL     string
+		wantGraphQL string
+		wantREST    string
+		wantErr     bool
+	}{
+		{baseURL: "", wantGraphQL: DefaultGraphQLURL, wantREST: DefaultRESTURL},
+		{baseURL: "https://api.github.com", wantGraphQL: DefaultGraphQLURL, wantREST: DefaultRESTURL},
+		{baseURL: "https://github.com/", wantGraphQL: DefaultGraphQLURL, wantREST: DefaultRESTURL},
+		{baseURL: "https://ghe.example.com", wantGraphQL: "https://ghe.example.com/api/graphql", wantREST: "https://ghe.example.com/api/v3"},
+		{baseURL: "https://ghe.example.com/api/v3/", wantGraphQL: "https://ghe.example.com/api/graphql", wantREST: "https://ghe.example.com/api/v3"},
+		{baseURL: "https://ghe.example.com/api/graphql", wantGraphQL: "https://ghe.example.com/api/graphql", wantREST: "https://ghe.example.com/api/v3"},
+		{baseURL: "ghe.example.com", wantErr: true},
+		{baseURL: "ftp://ghe.example.com", wantErr: true},
+		{baseURL: "https://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			graphqlURL, restURL, err := Endpoints(tt.baseURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Endpoints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if graphqlURL != tt.wantGraphQL || restURL != tt.wantREST {
+				t.Errorf("Endpoints() = %q, %q, want %q, %q", graphqlURL, restURL, tt.wantGraphQL, tt.wantREST)
+			}
+		})
+	}
+}
+
+func TestClientHost(t *testing.T) {
+	if got := NewClient(DefaultGraphQLURL, DefaultRESTURL, &http.Client{}).Host(); got != "api.github.com" {
+		t.Errorf("Host() = %q, want api.github.com", got)
+	}
+	if got := NewClient("https://GHE.example.com/api/graphql", "https://GHE.example.com/api/v3", &http.Client{}).Host(); got != "ghe.example.com" {
+		t.Errorf("Host() = %q, want ghe.example.com", got)
+	}
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	resetAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		threshold int
+		strategy  RateLimitStrategy
+		remaining int
+		wantErr   bool
+	}{
+		{name: "disabled", threshold: 0, strategy: RateLimitAbort, remaining: 0},
+		{name: "above threshold", threshold: 50, strategy: RateLimitAbort, remaining: 51},
+		{name: "below threshold aborts", threshold: 50, strategy: RateLimitAbort, remaining: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{}
+			client.SetRateLimit(tt.threshold, tt.strategy)
+			err := client.checkRateLimit(context.Background(), "GraphQL", tt.remaining, resetAt)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkRateLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckRateLimitSleepHonorsContext(t *testing.T) {
+	client := &Client{}
+	client.SetRateLimit(50, RateLimitSleep)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.checkRateLimit(ctx, "REST", 0, time.Now().Add(time.Hour)); err != context.Canceled {
+		t.Errorf("checkRateLimit() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSetPageSize(t *testing.T) {
+	var first interface{}
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		first = req.Variables["first"]
+		return pullRequestsPage(1, 2, false)
+	}, nil)
+
+	for _, size := range []int{0, 101} {
+		if err := client.SetPageSize(size); err == nil {
+			t.Errorf("SetPageSize(%d) succeeded, want an error", size)
+		}
+	}
+	if err := client.SetPageSize(25); err != nil {
+		t.Fatalf("SetPageSize(25) error = %v", err)
+	}
+	if _, err := FetchPullRequests(context.Background(), client, "rancher", "rancher", nil, 0); err != nil {
+		t.Fatalf("FetchPullRequests() error = %v", err)
+	}
+	if first != float64(25) {
+		t.Errorf("first = %v, want 25", first)
+	}
+}
@@ -0,0 +1,43 @@
+package publicprs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/machinebox/graphql"
+)
+
+// AddComment posts a comment with the given Markdown body on the PR or issue with the given global
+// ID, and returns the global ID of the comment.
+func AddComment(ctx context.Context, client *Client, subjectID, body string) (string, error) {
+	req := graphql.NewRequest(`
+		mutation($subjectID: ID!, $body: String!) {
+			addComment(input: {subjectId: $subjectID, body: $body}) {
+				commentEdge {
+					node {
+						id
+					}
+				}
+			}
+		}
+	`)
+
+	req.Var("subjectID", subjectID)
+	req.Var("body", body)
+
+	var resp struct {
+		AddComment struct {
+			CommentEdge struct {
+				Node struct {
+					ID string `json:"id"`
+				} `json:"node"`
+			} `json:"commentEdge"`
+		} `json:"addComment"`
+	}
+
+	if err := client.run(ctx, req, &resp); err != nil {
+		return "", fmt.Errorf("error adding comment: %w", err)
+	}
+
+	return resp.AddComment.CommentEdge.Node.ID, nil
+}
@@ -0,0 +1,20 @@
+package publicprs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAddComment(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if req.Variables["subjectID"] != "PR_12" || req.Variables["body"] != "Thanks @jdoe!" {
+			t.Errorf("unexpected variables %v", req.Variables)
+		}
+		return map[string]interface{}{"addComment": map[string]interface{}{"commentEdge": map[string]interface{}{"node": map[string]interface{}{"id": "IC_1"}}}}
+	}, nil)
+
+	id, err := AddComment(context.Background(), client, "PR_12", "Thanks @jdoe!")
+	if err != nil || id != "IC_1" {
+		t.Errorf("AddComment() = %q, %v, want IC_1", id, err)
+	}
+}
@@ -0,0 +1,50 @@
+package publicprs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors wrapped by the functions of this package for failures callers may want to handle apart
+// from others, for example with distinct exit codes.  Test for them with errors.Is.
+var (
+	// ErrProjectNotFound is wrapped when a project does not exist or the token cannot read it.
+	ErrProjectNotFound = errors.New("project not found")
+	// ErrRateLimited is wrapped when GitHub still rejects a request because of a rate limit once
+	// the retries are exhausted, or when the rate limit runs low with RateLimitAbort.
+	ErrRateLimited = errors.New("rate limited by GitHub")
+	// ErrTokenMissing is wrapped when GitHub rejects the credentials of a request, because no token
+	// was sent or it is invalid, revoked or expired.
+	ErrTokenMissing = errors.New("GitHub token missing or invalid")
+)
+
+// statusError returns an error wrapping ErrTokenMissing or ErrRateLimited when resp was rejected
+// for one of these reasons, and nil otherwise.
+func statusError(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%w: received response %d", ErrTokenMissing, resp.StatusCode)
+	case isRateLimited(resp):
+		return fmt.Errorf("%w: received response %d", ErrRateLimited, resp.StatusCode)
+	}
+	return nil
+}
+
+// statusTransport fails the requests statusError classifies.  GitHub answers them with a JSON body
+// that the GraphQL client would otherwise decode as a response without data or errors.
+type statusTransport struct {
+	base http.RoundTripper
+}
+
+func (t *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := statusError(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
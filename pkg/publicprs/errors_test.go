@@ -0,0 +1,64 @@
+package publicprs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		body    string
+		wantErr error
+	}{
+		{name: "bad credentials", status: http.StatusUnauthorized, body: `{"message": "Bad credentials"}`, wantErr: ErrTokenMissing},
+		{name: "secondary rate limit", status: http.StatusForbidden, header: http.Header{"Retry-After": {"60"}}, body: `{"message": "You have exceeded a secondary rate limit"}`, wantErr: ErrRateLimited},
+		{name: "rate limited query", status: http.StatusOK, body: `{"data": null, "errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded for user ID 1."}]}`, wantErr: ErrRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, values := range tt.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			client := NewClient(server.URL+"/graphql", server.URL, server.Client())
+
+			if _, _, err := Viewer(context.Background(), client); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GraphQL error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.status != http.StatusOK {
+				if err := FetchOrgMembers(context.Background(), client, "rancher", make(map[string]bool)); !errors.Is(err, tt.wantErr) {
+					t.Errorf("REST error = %v, want %v", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitAbortError(t *testing.T) {
+	client := NewClient("", "", http.DefaultClient)
+	client.SetRateLimit(100, RateLimitAbort)
+	err := client.checkRateLimit(context.Background(), "GraphQL", 10, time.Now().Add(time.Hour))
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("checkRateLimit() error = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestGetProjectV2IDNotFoundError(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		return map[string]interface{}{"organization": map[string]interface{}{"projectV2": nil}, "user": nil}
+	}, nil)
+	if _, err := GetProjectV2ID(context.Background(), client, "rancher", 79); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("GetProjectV2ID() error = %v, want ErrProjectNotFound", err)
+	}
+}
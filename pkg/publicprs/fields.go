@@ -0,0 +1,102 @@
+package publicprs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/machinebox/graphql"
+)
+
+// GetSingleSelectOption looks up a single select field of the project by name, and one of the
+// field's options by name, returning their IDs.  Names are compared case-insensitively.
+func GetSingleSelectOption(ctx context.Context, client *Client, projectID, fieldName, optionName string) (string, string, error) {
+	req := graphql.NewRequest(`
+		query($projectID: ID!, $fieldName: String!) {
+			node(id: $projectID) {
+				... on ProjectV2 {
+					field(name: $fieldName) {
+						__typename
+						... on ProjectV2SingleSelectField {
+							id
+							options {
+								id
+								name
+							}
+						}
+					}
+				}
+			}
+		}
+	`)
+
+	req.Var("projectID", projectID)
+	req.Var("fieldName", fieldName)
+
+	var resp struct {
+		Node struct {
+			Field *struct {
+				Typename string `json:"__typename"`
+				ID       string
+				Options  []struct {
+					ID   string
+					Name string
+				}
+			}
+		}
+	}
+
+	if err := client.run(ctx, req, &resp); err != nil {
+		return "", "", fmt.Errorf("error fetching project field %q: %w", fieldName, err)
+	}
+
+	field := resp.Node.Field
+	if field == nil {
+		return "", "", fmt.Errorf("project has no field named %q", fieldName)
+	}
+	if field.Typename != "ProjectV2SingleSelectField" {
+		return "", "", fmt.Errorf("project field %q is not a single select field", fieldName)
+	}
+
+	var names []string
+	for _, option := range field.Options {
+		if strings.EqualFold(option.Name, optionName) {
+			return field.ID, option.ID, nil
+		}
+		names = append(names, option.Name)
+	}
+
+	return "", "", fmt.Errorf("project field %q has no option %q (available: %s)", fieldName, optionName, strings.Join(names, ", "))
+}
+
+// SetSingleSelectValue sets a single select field of a project item to the given option.
+func SetSingleSelectValue(ctx context.Context, client *Client, projectID, itemID, fieldID, optionID string) error {
+	req := graphql.NewRequest(`
+		mutation($projectID: ID!, $itemID: ID!, $fieldID: ID!, $optionID: String!) {
+			updateProjectV2ItemFieldValue(input: {projectId: $projectID, itemId: $itemID, fieldId: $fieldID, value: {singleSelectOptionId: $optionID}}) {
+				projectV2Item {
+					id
+				}
+			}
+		}
+	`)
+
+	req.Var("projectID", projectID)
+	req.Var("itemID", itemID)
+	req.Var("fieldID", fieldID)
+	req.Var("optionID", optionID)
+
+	var resp struct {
+		UpdateProjectV2ItemFieldValue struct {
+			ProjectV2Item struct {
+				ID string `json:"id"`
+			} `json:"projectV2Item"`
+		} `json:"updateProjectV2ItemFieldValue"`
+	}
+
+	if err := client.run(ctx, req, &resp); err != nil {
+		return fmt.Errorf("error updating project item field: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package publicprs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetSingleSelectOption(t *testing.T) {
+	statusField := map[string]interface{}{
+		"__typename": "ProjectV2SingleSelectField",
+		"id":         "status-field",
+		"options": []map[string]interface{}{
+			{"id": "todo", "name": "Todo"},
+			{"id": "triage", "name": "Needs Triage"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		field      interface{}
+		option     string
+		wantField  string
+		wantOption string
+		wantErr    bool
+	}{
+		{name: "matching option", field: statusField, option: "needs triage", wantField: "status-field", wantOption: "triage"},
+		{name: "missing option", field: statusField, option: "Done", wantErr: true},
+		{name: "missing field", field: nil, option: "Todo", wantErr: true},
+		{name: "not a single select field", field: map[string]interface{}{"__typename": "ProjectV2Field"}, option: "Todo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(req graphqlRequest) interface{} {
+				return map[string]interface{}{"node": map[string]interface{}{"field": tt.field}}
+			}, nil)
+
+			fieldID, optionID, err := GetSingleSelectOption(context.Background(), client, "project", "Status", tt.option)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSingleSelectOption() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fieldID != tt.wantField || optionID != tt.wantOption {
+				t.Errorf("GetSingleSelectOption() = %q, %q, want %q, %q", fieldID, optionID, tt.wantField, tt.wantOption)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package publicprs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// operationName matches the name of a named GraphQL query or mutation.
+var operationName = regexp.MustCompile(`^\s*(?:query|mutation)\s+(\w+)`)
+
+// newFixtureClient returns a Client answering from recorded GitHub responses under testdata/dir.
+//
+// A GraphQL request is answered with graphql/<operation>.json, where operation is the name of the
+// query, or with graphql/<operation>.<cursor>.json when it asks for the page after cursor.  The
+// files hold the whole response, including any errors.  A REST request is answered with
+// rest/<path>.json, or with rest/<path>.page<N>.json for page N > 1.  Requests without a fixture
+// fail the test.
+func newFixtureClient(t *testing.T, dir string) *Client {
+	t.Helper()
+	root := filepath.Join("testdata", dir)
+
+	graphqlHandler := func(req graphqlRequest) interface{} {
+		match := operationName.FindStringSubmatch(req.Query)
+		if match == nil {
+			t.Errorf("GraphQL request without an operation name has no fixture: %s", req.Query)
+			return nil
+		}
+		name := match[1]
+		if cursor, _ := req.Variables["cursor"].(string); cursor != "" {
+			name += "." + url.PathEscape(cursor)
+		}
+		var resp graphqlResponse
+		readFixture(t, filepath.Join(root, "graphql", name+".json"), &resp)
+		return resp
+	}
+
+	rest := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path
+		if page := r.URL.Query().Get("page"); page != "" && page != "1" {
+			name += ".page" + page
+		}
+		var body json.RawMessage
+		if !readFixture(t, filepath.Join(root, "rest", filepath.FromSlash(name)+".json"), &body) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+
+	return newTestClient(t, graphqlHandler, rest)
+}
+
+// readFixture decodes the JSON fixture at path into v, failing the test and returning false when it
+// cannot be read.
+func readFixture(t *testing.T, path string, v interface{}) bool {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("missing fixture: %v", err)
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Errorf("decoding fixture %s: %v", path, err)
+		return false
+	}
+	return true
+}
+
+func TestFixtureExternalPRs(t *testing.T) {
+	client := newFixtureClient(t, "external-prs")
+	ctx := context.Background()
+
+	members := make(map[string]bool)
+	if err := FetchOrgMembers(ctx, client, "rancher", members); err != nil {
+		t.Fatalf("FetchOrgMembers() error = %v", err)
+	}
+	if len(members) != 102 || !members["adoe"] {
+		t.Fatalf("got %d members, want the 102 members of both pages", len(members))
+	}
+
+	prs, stats, err := FetchExternalPRs(ctx, client, Options{Owner: "rancher", Repos: []string{"rancher"}, Members: members})
+	if err != nil {
+		t.Fatalf("FetchExternalPRs() error = %v", err)
+	}
+	var numbers []int
+	for _, pr := range prs {
+		numbers = append(numbers, pr.Number)
+	}
+	// #45002 is by a bot, #45003 and #45005 by members, and #45006 by a deleted account
+	if want := []int{45001, 45004}; !slices.Equal(numbers, want) {
+		t.Errorf("external PRs = %v, want %v", numbers, want)
+	}
+	if stats.Scanned != 6 || stats.Bots != 1 {
+		t.Errorf("stats = %+v, want 6 scanned and 1 bot", stats)
+	}
+}
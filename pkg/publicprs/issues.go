@@ -0,0 +1,206 @@
+package publicprs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/machinebox/graphql"
+)
+
+// Issue is an issue as reported by publicprs.
+type Issue struct {
+	// ID is the global node ID of the issue, used to add it to projects.
+	ID          string    `json:"id"`
+	Owner       string    `json:"owner"`
+	Repo        string    `json:"repo"`
+	Number      int       `json:"number"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Author      string    `json:"author"`
+	AuthorIsBot bool      `json:"authorIsBot"`
+	Labels      []string  `json:"labels,omitempty"`
+}
+
+// NameWithOwner returns the full name of the issue's repository, such as rancher/dashboard.
+func (i Issue) NameWithOwner() string {
+	return i.Owner + "/" + i.Repo
+}
+
+// FetchExternalIssues fetches the open issues of every repository in opts and returns the ones
+// authored by users outside of opts.Members, sorted by creation date, oldest first.  Of the
+// filters of opts, only the bot, deleted author, author pattern, creation and update date and label
+// filters apply to issues.  Repositories GitHub cannot resolve are skipped as by FetchExternalPRs.
+func FetchExternalIssues(ctx context.Context, client *Client, opts Options) ([]Issue, error) {
+	var issues []Issue
+	var unavailable []string
+	for _, repo := range opts.Repos {
+		owner, name := SplitRepo(opts.Owner, repo)
+		repoIssues, err := FetchIssues(ctx, client, owner, name)
+		if errors.Is(err, errRepositoryUnavailable) && len(opts.Repos) > 1 {
+			slog.Warn("Skipping repository", "repo", owner+"/"+name, "err", err)
+			unavailable = append(unavailable, repo)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error fetching issues from %s/%s: %w", owner, name, err)
+		}
+		for _, issue := range repoIssues {
+			if IsExternalIssue(issue, opts) {
+				issues = append(issues, issue)
+			}
+		}
+	}
+	if len(opts.Repos) > 0 && len(unavailable) == len(opts.Repos) {
+		return nil, fmt.Errorf("error fetching issues: none of the repositories %v are accessible", unavailable)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].CreatedAt.Before(issues[j].CreatedAt)
+	})
+	return issues, nil
+}
+
+// IsExternalIssue reports whether issue was authored outside of opts.Members and passes the bot,
+// deleted author, author pattern, creation and update date and label filters of opts.
+func IsExternalIssue(issue Issue, opts Options) bool {
+	if _, isMember := opts.Members[issue.Author]; isMember {
+		return false
+	}
+	if !opts.IncludeBots && (issue.AuthorIsBot || slices.Contains(opts.BotsToExclude, issue.Author)) {
+		return false
+	}
+	if issue.Author == DeletedUser && !opts.IncludeGhost {
+		return false
+	}
+	if opts.AuthorPattern != nil && opts.AuthorPattern.MatchString(issue.Author) {
+		return false
+	}
+	if !withinWindow(issue.CreatedAt, opts.CreatedAfter, opts.CreatedBefore) || !withinWindow(issue.UpdatedAt, opts.UpdatedAfter, opts.UpdatedBefore) {
+		return false
+	}
+	return HasLabels(issue.Labels, opts.Labels, opts.RequireAll)
+}
+
+// FetchIssues fetches the open issues of a repository.
+func FetchIssues(ctx context.Context, client *Client, owner, repo string) ([]Issue, error) {
+	cursor := ""
+	var issues []Issue
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		req := graphql.NewRequest(`
+			query Issues($owner: String!, $repo: String!, $cursor: String) {
+				repository(owner: $owner, name: $repo) {
+					issues(first: 100, after: $cursor, states: OPEN) {
+						nodes {
+							id
+							number
+							title
+							url
+							createdAt
+							updatedAt
+							author {
+								__typename
+								login
+							}
+							labels(first: 100) {
+								nodes {
+									name
+								}
+							}
+						}
+						pageInfo {
+							endCursor
+							hasNextPage
+						}
+					}
+				}
+				rateLimit {
+					cost
+					remaining
+					resetAt
+				}
+			}
+		`)
+
+		req.Var("owner", owner)
+		req.Var("repo", repo)
+		req.Var("cursor", cursor)
+
+		var resp struct {
+			Repository *struct {
+				Issues struct {
+					Nodes []struct {
+						ID        string
+						Number    int
+						Title     string
+						URL       string
+						CreatedAt string
+						UpdatedAt string
+						Author    *struct {
+							Typename string `json:"__typename"`
+							Login    string
+						}
+						Labels labelPage
+					}
+					PageInfo struct {
+						EndCursor   string
+						HasNextPage bool
+					}
+				}
+			}
+			RateLimit rateLimit
+		}
+
+		if err := client.runPartial(ctx, req, &resp); err != nil {
+			return nil, fmt.Errorf("error fetching issues: %w", err)
+		}
+		if resp.Repository == nil {
+			return nil, errRepositoryUnavailable
+		}
+
+		slog.Debug("Fetched issue page", "repo", owner+"/"+repo, "count", len(resp.Repository.Issues.Nodes))
+
+		for _, issue := range resp.Repository.Issues.Nodes {
+			// GitHub returns a null author once the account has been deleted
+			author, authorIsBot := DeletedUser, false
+			if issue.Author != nil && issue.Author.Login != "" {
+				author, authorIsBot = issue.Author.Login, issue.Author.Typename == "Bot"
+			}
+			issues = append(issues, Issue{
+				ID:          issue.ID,
+				Owner:       owner,
+				Repo:        repo,
+				Number:      issue.Number,
+				Title:       issue.Title,
+				URL:         issue.URL,
+				CreatedAt:   parseTime(issue.CreatedAt, "repo", owner+"/"+repo, "issue", issue.Number, "field", "createdAt"),
+				UpdatedAt:   parseTime(issue.UpdatedAt, "repo", owner+"/"+repo, "issue", issue.Number, "field", "updatedAt"),
+				Author:      author,
+				AuthorIsBot: authorIsBot,
+				Labels:      issue.Labels.names(),
+			})
+		}
+
+		if err := client.checkRateLimit(ctx, "GraphQL", resp.RateLimit.Remaining, resp.RateLimit.ResetAt); err != nil {
+			return nil, err
+		}
+
+		if !resp.Repository.Issues.PageInfo.HasNextPage {
+			break
+		}
+		cursor = resp.Repository.Issues.PageInfo.EndCursor
+	}
+
+	return issues, nil
+}
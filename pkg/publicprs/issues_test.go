@@ -0,0 +1,58 @@
+package publicprs
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestFetchExternalIssues(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if req.Variables["repo"] != "rancher" {
+			t.Errorf("repo = %v, want rancher", req.Variables["repo"])
+		}
+		issue := func(number int, createdAt string, author interface{}) map[string]interface{} {
+			return map[string]interface{}{
+				"id":        "I_" + createdAt,
+				"number":    number,
+				"title":     "Something is broken",
+				"url":       "https://github.com/rancher/rancher/issues/1",
+				"createdAt": createdAt,
+				"updatedAt": createdAt,
+				"author":    author,
+				"labels":    map[string]interface{}{"nodes": []interface{}{map[string]interface{}{"name": "kind/bug"}}},
+			}
+		}
+		return map[string]interface{}{
+			"repository": map[string]interface{}{
+				"issues": map[string]interface{}{
+					"nodes": []interface{}{
+						issue(3, "2024-03-01T00:00:00Z", map[string]interface{}{"__typename": "User", "login": "outsider"}),
+						issue(2, "2024-02-01T00:00:00Z", map[string]interface{}{"__typename": "User", "login": "member"}),
+						issue(4, "2024-04-01T00:00:00Z", map[string]interface{}{"__typename": "Bot", "login": "renovate"}),
+						issue(5, "2024-05-01T00:00:00Z", nil),
+						issue(1, "2024-01-01T00:00:00Z", map[string]interface{}{"__typename": "User", "login": "newcomer"}),
+					},
+					"pageInfo": map[string]interface{}{"hasNextPage": false},
+				},
+			},
+		}
+	}, nil)
+
+	opts := Options{Owner: "rancher", Repos: []string{"rancher"}, Members: map[string]bool{"member": true}}
+	issues, err := FetchExternalIssues(context.Background(), client, opts)
+	if err != nil {
+		t.Fatalf("FetchExternalIssues() error = %v", err)
+	}
+	var numbers []int
+	for _, issue := range issues {
+		numbers = append(numbers, issue.Number)
+	}
+	// Members, bots and deleted authors are skipped, and issues are sorted by creation date
+	if !slices.Equal(numbers, []int{1, 3}) {
+		t.Errorf("issues = %v, want [1 3]", numbers)
+	}
+	if issues[0].Author != "newcomer" || issues[0].NameWithOwner() != "rancher/rancher" || !slices.Equal(issues[0].Labels, []string{"kind/bug"}) {
+		t.Errorf("issue = %+v", issues[0])
+	}
+}
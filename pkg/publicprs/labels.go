@@ -0,0 +1,63 @@
+package publicprs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/machinebox/graphql"
+)
+
+// LabelID fetches the global ID of the label with the given name in the owner/repo repository, as
+// expected by AddLabels.  Label names are matched case-insensitively, like GitHub does.
+func LabelID(ctx context.Context, client *Client, owner, repo, name string) (string, error) {
+	req := graphql.NewRequest(`
+		query($owner: String!, $repo: String!, $name: String!) {
+			repository(owner: $owner, name: $repo) {
+				label(name: $name) {
+					id
+				}
+			}
+		}
+	`)
+	req.Var("owner", owner)
+	req.Var("repo", repo)
+	req.Var("name", name)
+
+	var resp struct {
+		Repository *struct {
+			Label *struct {
+				ID string
+			}
+		}
+	}
+	if err := client.run(ctx, req, &resp); err != nil {
+		return "", fmt.Errorf("error fetching label %q of %s/%s: %w", name, owner, repo, err)
+	}
+	if resp.Repository == nil {
+		return "", fmt.Errorf("repository %s/%s not found", owner, repo)
+	}
+	if resp.Repository.Label == nil {
+		return "", fmt.Errorf("label %q does not exist in %s/%s", name, owner, repo)
+	}
+	return resp.Repository.Label.ID, nil
+}
+
+// AddLabels applies the labels with the given global IDs to the PR or issue with the given global
+// ID, keeping its other labels.
+func AddLabels(ctx context.Context, client *Client, labelableID string, labelIDs []string) error {
+	req := graphql.NewRequest(`
+		mutation($labelableID: ID!, $labelIDs: [ID!]!) {
+			addLabelsToLabelable(input: {labelableId: $labelableID, labelIds: $labelIDs}) {
+				clientMutationId
+			}
+		}
+	`)
+	req.Var("labelableID", labelableID)
+	req.Var("labelIDs", labelIDs)
+
+	var resp struct{}
+	if err := client.run(ctx, req, &resp); err != nil {
+		return fmt.Errorf("error adding labels: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package publicprs
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLabelID(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if req.Variables["repo"] != "rancher" {
+			return map[string]interface{}{"repository": nil}
+		}
+		if req.Variables["name"] != "external-contrib" {
+			return map[string]interface{}{"repository": map[string]interface{}{"label": nil}}
+		}
+		return map[string]interface{}{"repository": map[string]interface{}{"label": map[string]interface{}{"id": "LA_1"}}}
+	}, nil)
+
+	if id, err := LabelID(context.Background(), client, "rancher", "rancher", "external-contrib"); err != nil || id != "LA_1" {
+		t.Errorf("LabelID() = %q, %v, want LA_1", id, err)
+	}
+	if _, err := LabelID(context.Background(), client, "rancher", "rancher", "missing"); err == nil || !strings.Contains(err.Error(), `label "missing" does not exist in rancher/rancher`) {
+		t.Errorf("LabelID() of a missing label error = %v", err)
+	}
+	if _, err := LabelID(context.Background(), client, "rancher", "gone", "external-contrib"); err == nil {
+		t.Error("LabelID() in a missing repository succeeded, want an error")
+	}
+}
+
+func TestAddLabels(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if !strings.Contains(req.Query, "addLabelsToLabelable") {
+			t.Errorf("unexpected query %s", req.Query)
+		}
+		if req.Variables["labelableID"] != "PR_12" || len(req.Variables["labelIDs"].([]interface{})) != 1 {
+			t.Errorf("unexpected variables %v", req.Variables)
+		}
+		return map[string]interface{}{"addLabelsToLabelable": map[string]interface{}{"clientMutationId": nil}}
+	}, nil)
+
+	if err := AddLabels(context.Background(), client, "PR_12", []string{"LA_1"}); err != nil {
+		t.Errorf("AddLabels() error = %v", err)
+	}
+}
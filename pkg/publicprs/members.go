@@ -0,0 +1,139 @@
+package publicprs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/machinebox/graphql"
+)
+
+// Member is an organization member as returned by the REST API.
+type Member struct {
+	Login string `json:"login"`
+}
+
+// FetchOrgMembers fetches all members from a GitHub organization using the REST API
+// This is using the REST API instead of graphql because we need ALL org members and MembersWithRole
+// doesn't give us the full list that we need.
+func FetchOrgMembers(ctx context.Context, client *Client, org string, members map[string]bool) error {
+	return fetchMembers(ctx, client, fmt.Sprintf("/orgs/%s/members", org), nil, members)
+}
+
+// OrgMemberCount returns the number of members of a GitHub organization as counted by GitHub,
+// including members who keep their membership private.  The REST organization endpoint doesn't
+// report a member count, so the count comes from GraphQL.  Comparing it to the number of members
+// FetchOrgMembers returned tells whether the token could only list the public members, as happens
+// with fine-grained tokens lacking the organization Members permission.
+func OrgMemberCount(ctx context.Context, client *Client, org string) (int, error) {
+	req := graphql.NewRequest(`
+		query($org: String!) {
+			organization(login: $org) {
+				membersWithRole {
+					totalCount
+				}
+			}
+		}
+	`)
+	req.Var("org", org)
+
+	var resp struct {
+		Organization *struct {
+			MembersWithRole struct {
+				TotalCount int
+			}
+		}
+	}
+	if err := client.run(ctx, req, &resp); err != nil {
+		return 0, fmt.Errorf("error fetching member count of %s: %w", org, err)
+	}
+	if resp.Organization == nil {
+		return 0, fmt.Errorf("organization %s not found", org)
+	}
+	return resp.Organization.MembersWithRole.TotalCount, nil
+}
+
+// FetchTeamMembers fetches all members of a team, identified by its slug, using the REST API.
+func FetchTeamMembers(ctx context.Context, client *Client, org, team string, members map[string]bool) error {
+	return fetchMembers(ctx, client, fmt.Sprintf("/orgs/%s/teams/%s/members", org, team), nil, members)
+}
+
+// FetchCollaborators fetches the collaborators of a repository using the REST API.  affiliation
+// selects which collaborators are returned: "outside", "direct" or "all", as documented for the
+// REST endpoint.  An empty affiliation uses GitHub's default of "all".
+func FetchCollaborators(ctx context.Context, client *Client, owner, repo, affiliation string, members map[string]bool) error {
+	params := url.Values{}
+	if affiliation != "" {
+		params.Set("affiliation", affiliation)
+	}
+	return fetchMembers(ctx, client, fmt.Sprintf("/repos/%s/%s/collaborators", owner, repo), params, members)
+}
+
+// FetchUserOrgs fetches the organizations that list user as a public member, using the REST API.
+// Private memberships are not visible to other users.
+func FetchUserOrgs(ctx context.Context, client *Client, user string, orgs map[string]bool) error {
+	return fetchMembers(ctx, client, fmt.Sprintf("/users/%s/orgs", user), nil, orgs)
+}
+
+// fetchMembers pages through a REST endpoint listing users and adds their logins to members.
+// params are added to the query string of every page request.
+func fetchMembers(ctx context.Context, client *Client, path string, params url.Values, members map[string]bool) error {
+	perPage := client.perPage()
+	page := 1
+
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("per_page", strconv.Itoa(perPage))
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		query.Set("page", strconv.Itoa(page))
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s?%s", client.restURL, path, query.Encode()), nil)
+		if err != nil {
+			return fmt.Errorf("error creating request: %v", err)
+		}
+
+		slog.Debug("Fetching members page", "path", path, "page", page)
+		resp, err := client.rest.Do(req)
+		if err != nil {
+			return fmt.Errorf("error making request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if err := statusError(resp); err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error: received non-OK response %d", resp.StatusCode)
+		}
+
+		var orgMembers []Member
+		if err := json.NewDecoder(resp.Body).Decode(&orgMembers); err != nil {
+			return fmt.Errorf("error decoding response: %v", err)
+		}
+
+		for _, member := range orgMembers {
+			members[member.Login] = true
+		}
+
+		if err := client.checkRESTRateLimit(ctx, resp); err != nil {
+			return err
+		}
+
+		if len(orgMembers) < perPage {
+			break
+		}
+		page++
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package publicprs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestFetchOrgMembers(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		wantPages int
+	}{
+		{name: "single partial page", total: 42, wantPages: 1},
+		{name: "two pages", total: 150, wantPages: 2},
+		{name: "exactly one full page", total: 100, wantPages: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+			client := newTestClient(t, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/orgs/rancher/members" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+					t.Errorf("Authorization = %q", got)
+				}
+				pages++
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+
+				var members []Member
+				for i := (page - 1) * perPage; i < min(page*perPage, tt.total); i++ {
+					members = append(members, Member{Login: fmt.Sprintf("user%d", i)})
+				}
+				json.NewEncoder(w).Encode(members)
+			}))
+
+			members := make(map[string]bool)
+			if err := FetchOrgMembers(context.Background(), client, "rancher", members); err != nil {
+				t.Fatalf("FetchOrgMembers() error = %v", err)
+			}
+			if len(members) != tt.total {
+				t.Errorf("got %d members, want %d", len(members), tt.total)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("fetched %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestFetchOrgMembersError(t *testing.T) {
+	client := newTestClient(t, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+
+	if err := FetchOrgMembers(context.Background(), client, "missing", map[string]bool{}); err == nil {
+		t.Fatal("FetchOrgMembers() expected an error for a non-OK response")
+	}
+}
+
+func TestFetchTeamMembers(t *testing.T) {
+	client := newTestClient(t, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/rancher/teams/core/members" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode([]Member{{Login: "alice"}, {Login: "bob"}})
+	}))
+
+	members := make(map[string]bool)
+	if err := FetchTeamMembers(context.Background(), client, "rancher", "core", members); err != nil {
+		t.Fatalf("FetchTeamMembers() error = %v", err)
+	}
+	if len(members) != 2 || !members["alice"] || !members["bob"] {
+		t.Errorf("FetchTeamMembers() members = %v", members)
+	}
+}
+
+func TestFetchUserOrgs(t *testing.T) {
+	client := newTestClient(t, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/jdoe/orgs" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode([]Member{{Login: "partner"}, {Login: "kubernetes"}})
+	}))
+
+	orgs := make(map[string]bool)
+	if err := FetchUserOrgs(context.Background(), client, "jdoe", orgs); err != nil {
+		t.Fatalf("FetchUserOrgs() error = %v", err)
+	}
+	if len(orgs) != 2 || !orgs["partner"] || !orgs["kubernetes"] {
+		t.Errorf("FetchUserOrgs() orgs = %v", orgs)
+	}
+}
+
+func TestFetchCollaborators(t *testing.T) {
+	client := newTestClient(t, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/rancher/dashboard/collaborators" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("affiliation"); got != "outside" {
+			t.Errorf("affiliation = %q, want outside", got)
+		}
+		if got := r.URL.Query().Get("per_page"); got != "100" {
+			t.Errorf("per_page = %q, want 100", got)
+		}
+		json.NewEncoder(w).Encode([]Member{{Login: "contractor"}, {Login: "partner"}})
+	}))
+
+	members := map[string]bool{"employee": true}
+	if err := FetchCollaborators(context.Background(), client, "rancher", "dashboard", "outside", members); err != nil {
+		t.Fatalf("FetchCollaborators() error = %v", err)
+	}
+	for _, login := range []string{"employee", "contractor", "partner"} {
+		if !members[login] {
+			t.Errorf("members is missing %s", login)
+		}
+	}
+}
+
+func TestMembersUseClientAuthentication(t *testing.T) {
+	auth := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth[r.URL.Path] = r.Header.Get("Authorization")
+		if r.URL.Path == "/graphql" {
+			w.Write([]byte(`{"data":{"repository":{"pullRequest":{"id":"PR_1"}}}}`))
+			return
+		}
+		json.NewEncoder(w).Encode([]Member{{Login: "user"}})
+	}))
+	defer server.Close()
+
+	httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test-token"}))
+	client := NewClient(server.URL+"/graphql", server.URL, httpClient)
+
+	if err := FetchOrgMembers(context.Background(), client, "rancher", make(map[string]bool)); err != nil {
+		t.Fatalf("FetchOrgMembers() error = %v", err)
+	}
+	if _, err := GetPullRequestID(context.Background(), client, "rancher", "rancher", 1); err != nil {
+		t.Fatalf("GetPullRequestID() error = %v", err)
+	}
+	for _, path := range []string{"/orgs/rancher/members", "/graphql"} {
+		if got := auth[path]; got != "Bearer test-token" {
+			t.Errorf("Authorization of %s = %q, want the client's token", path, got)
+		}
+	}
+}
+
+func TestOrgMemberCount(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if req.Variables["org"] != "rancher" {
+			return map[string]interface{}{"organization": nil}
+		}
+		return map[string]interface{}{"organization": map[string]interface{}{"membersWithRole": map[string]interface{}{"totalCount": 412}}}
+	}, nil)
+
+	count, err := OrgMemberCount(context.Background(), client, "rancher")
+	if err != nil || count != 412 {
+		t.Errorf("OrgMemberCount() = %d, %v, want 412", count, err)
+	}
+	if _, err := OrgMemberCount(context.Background(), client, "nowhere"); err == nil {
+		t.Error("OrgMemberCount() of a missing organization succeeded, want an error")
+	}
+}
@@ -0,0 +1,285 @@
+package publicprs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Option configures the Options built by NewOptions.
+type Option func(*Options) error
+
+// NewOptions returns the Options for scanning the repositories of owner, each given by name or as
+// owner/name, or the PRs found by WithSearchQuery, configured by opts in order.  Setting the
+// fields of Options directly is equivalent; options only make the configuration read fluently and
+// validate it as it is built.
+func NewOptions(owner string, repos []string, opts ...Option) (Options, error) {
+	options := Options{Owner: owner, Repos: repos}
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return options, err
+		}
+	}
+	if len(repos) == 0 && options.SearchQuery == "" {
+		return options, errors.New("no repositories to scan")
+	}
+	return options, nil
+}
+
+// WithMembers sets the logins considered internal, such as those fetched by FetchOrgMembers.
+func WithMembers(members map[string]bool) Option {
+	return func(o *Options) error {
+		o.Members = members
+		return nil
+	}
+}
+
+// WithState fetches the PRs in state, one of the names accepted by PullRequestStates.
+func WithState(state string) Option {
+	return func(o *Options) error {
+		states, err := PullRequestStates(state)
+		if err != nil {
+			return err
+		}
+		o.States = states
+		return nil
+	}
+}
+
+// WithSearchQuery fetches the PRs matching a GitHub search query instead of the PRs of the
+// repositories, as described by Options.SearchQuery.
+func WithSearchQuery(query string) Option {
+	return func(o *Options) error {
+		if strings.TrimSpace(query) == "" {
+			return errors.New("empty search query")
+		}
+		o.SearchQuery = query
+		return nil
+	}
+}
+
+// WithSince reports only PRs created at or after since.
+func WithSince(since time.Time) Option {
+	return func(o *Options) error {
+		o.CreatedAfter = since
+		return nil
+	}
+}
+
+// WithUpdatedSince reports only PRs last updated at or after since.
+func WithUpdatedSince(since time.Time) Option {
+	return func(o *Options) error {
+		o.UpdatedAfter = since
+		return nil
+	}
+}
+
+// WithCreatedBetween reports only PRs created within after and before.  A zero time means no bound.
+func WithCreatedBetween(after, before time.Time) Option {
+	return func(o *Options) error {
+		o.CreatedAfter, o.CreatedBefore = after, before
+		return nil
+	}
+}
+
+// WithUpdatedBetween reports only PRs last updated within after and before.  A zero time means no
+// bound.
+func WithUpdatedBetween(after, before time.Time) Option {
+	return func(o *Options) error {
+		o.UpdatedAfter, o.UpdatedBefore = after, before
+		return nil
+	}
+}
+
+// WithIncludeBots reports PRs authored by bots.
+func WithIncludeBots() Option {
+	return func(o *Options) error {
+		o.IncludeBots = true
+		return nil
+	}
+}
+
+// WithIncludeBody fetches the description of each PR into PullRequest.Body.
+func WithIncludeBody() Option {
+	return func(o *Options) error {
+		o.IncludeBody = true
+		return nil
+	}
+}
+
+// WithBotsToExclude adds logins to skip as bots, such as App accounts reported as users.
+func WithBotsToExclude(logins ...string) Option {
+	return func(o *Options) error {
+		o.BotsToExclude = append(o.BotsToExclude, logins...)
+		return nil
+	}
+}
+
+// WithLabels reports only PRs carrying at least one of labels, or all of them with requireAll.
+func WithLabels(requireAll bool, labels ...string) Option {
+	return func(o *Options) error {
+		o.Labels = labels
+		o.RequireAll = requireAll
+		return nil
+	}
+}
+
+// WithBaseBranches reports only PRs targeting one of branches.
+func WithBaseBranches(branches ...string) Option {
+	return func(o *Options) error {
+		o.BaseBranches = branches
+		return nil
+	}
+}
+
+// WithMaxPRs stops fetching once limit PRs have been scanned.
+func WithMaxPRs(limit int) Option {
+	return func(o *Options) error {
+		if limit < 0 {
+			return errors.New("invalid maximum number of PRs: must not be negative")
+		}
+		o.MaxPRs = limit
+		return nil
+	}
+}
+
+// WithOrder sorts the reported PRs by the sort key accepted by PullRequestOrder.
+func WithOrder(key string) Option {
+	return func(o *Options) error {
+		order, err := PullRequestOrder(key)
+		if err != nil {
+			return err
+		}
+		o.Order = order
+		return nil
+	}
+}
+
+// WithIncludeGhost reports PRs whose author account has been deleted.
+func WithIncludeGhost() Option {
+	return func(o *Options) error {
+		o.IncludeGhost = true
+		return nil
+	}
+}
+
+// WithExcludeDrafts skips draft PRs.
+func WithExcludeDrafts() Option {
+	return func(o *Options) error {
+		if o.OnlyDrafts {
+			return errors.New("draft PRs cannot be both excluded and the only ones reported")
+		}
+		o.ExcludeDrafts = true
+		return nil
+	}
+}
+
+// WithOnlyDrafts reports draft PRs only.
+func WithOnlyDrafts() Option {
+	return func(o *Options) error {
+		if o.ExcludeDrafts {
+			return errors.New("draft PRs cannot be both excluded and the only ones reported")
+		}
+		o.OnlyDrafts = true
+		return nil
+	}
+}
+
+// WithNeedsReview reports only PRs without any review or review decision.
+func WithNeedsReview() Option {
+	return func(o *Options) error {
+		o.NeedsReview = true
+		return nil
+	}
+}
+
+// WithUnassigned reports only PRs without any assignee.
+func WithUnassigned() Option {
+	return func(o *Options) error {
+		o.Unassigned = true
+		return nil
+	}
+}
+
+// WithGreenOnly reports only PRs that are ready to merge.
+func WithGreenOnly() Option {
+	return func(o *Options) error {
+		o.GreenOnly = true
+		return nil
+	}
+}
+
+// WithLinkedOnly reports only PRs that close at least one issue.
+func WithLinkedOnly() Option {
+	return func(o *Options) error {
+		o.LinkedOnly = true
+		return nil
+	}
+}
+
+// WithFromForks reports only PRs whose changes come from a fork owned by someone else.
+func WithFromForks() Option {
+	return func(o *Options) error {
+		o.FromForks = true
+		return nil
+	}
+}
+
+// WithMilestone reports only PRs assigned to the milestone with this title or number.
+func WithMilestone(milestone string) Option {
+	return func(o *Options) error {
+		o.Milestone = milestone
+		return nil
+	}
+}
+
+// WithLines reports only PRs whose diff size is between minLines and maxLines.  Zero means no bound.
+func WithLines(minLines, maxLines int) Option {
+	return func(o *Options) error {
+		if minLines < 0 || maxLines < 0 || (maxLines > 0 && minLines > maxLines) {
+			return fmt.Errorf("invalid line bounds %d and %d", minLines, maxLines)
+		}
+		o.MinLines, o.MaxLines = minLines, maxLines
+		return nil
+	}
+}
+
+// WithPathPrefixes reports only PRs changing at least one file that matches one of patterns.
+func WithPathPrefixes(patterns ...string) Option {
+	return func(o *Options) error {
+		o.PathPrefixes = patterns
+		return nil
+	}
+}
+
+// WithAssociations reports only PRs whose author association is one of associations.
+func WithAssociations(associations ...string) Option {
+	return func(o *Options) error {
+		for _, association := range associations {
+			if !slices.Contains(AuthorAssociations, association) {
+				return fmt.Errorf("invalid author association %q: expected one of %s", association, strings.Join(AuthorAssociations, ", "))
+			}
+		}
+		o.Associations = associations
+		return nil
+	}
+}
+
+// WithAuthorPattern skips PRs whose author login matches pattern.
+func WithAuthorPattern(pattern *regexp.Regexp) Option {
+	return func(o *Options) error {
+		o.AuthorPattern = pattern
+		return nil
+	}
+}
+
+// WithProgress calls progress after each page of PRs is fetched.
+func WithProgress(progress ProgressFunc) Option {
+	return func(o *Options) error {
+		o.Progress = progress
+		return nil
+	}
+}
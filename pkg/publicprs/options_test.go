@@ -0,0 +1,84 @@
+package publicprs
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewOptions(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts, err := NewOptions("rancher", []string{"rancher", "rancher/dashboard"},
+		WithMembers(map[string]bool{"member": true}),
+		WithState("merged"),
+		WithSince(since),
+		WithBotsToExclude("ci-robot"),
+		WithLabels(true, "area/ui", "kind/bug"),
+		WithMaxPRs(500),
+		WithOrder("-number"),
+	)
+	if err != nil {
+		t.Fatalf("NewOptions() error = %v", err)
+	}
+	if opts.Owner != "rancher" || !slices.Equal(opts.Repos, []string{"rancher", "rancher/dashboard"}) || !opts.Members["member"] {
+		t.Errorf("opts = %+v", opts)
+	}
+	if !slices.Equal(opts.States, []string{"MERGED"}) || !opts.CreatedAfter.Equal(since) || opts.MaxPRs != 500 {
+		t.Errorf("states = %v, created after = %v, max PRs = %d", opts.States, opts.CreatedAfter, opts.MaxPRs)
+	}
+	if !slices.Equal(opts.BotsToExclude, []string{"ci-robot"}) || !slices.Equal(opts.Labels, []string{"area/ui", "kind/bug"}) || !opts.RequireAll {
+		t.Errorf("bots = %v, labels = %v, require all = %v", opts.BotsToExclude, opts.Labels, opts.RequireAll)
+	}
+	if opts.Order == nil || !opts.Order(PullRequest{Number: 2}, PullRequest{Number: 1}) {
+		t.Error("Order does not sort by descending number")
+	}
+}
+
+func TestNewOptionsFilters(t *testing.T) {
+	after, before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts, err := NewOptions("rancher", []string{"rancher"},
+		WithUpdatedBetween(after, before),
+		WithExcludeDrafts(),
+		WithNeedsReview(),
+		WithLines(10, 500),
+		WithMilestone("v2.9"),
+		WithAssociations("FIRST_TIME_CONTRIBUTOR"),
+		WithPathPrefixes("pkg/"),
+	)
+	if err != nil {
+		t.Fatalf("NewOptions() error = %v", err)
+	}
+	if !opts.UpdatedAfter.Equal(after) || !opts.UpdatedBefore.Equal(before) || !opts.CreatedAfter.IsZero() {
+		t.Errorf("updated window = %v..%v, created after = %v", opts.UpdatedAfter, opts.UpdatedBefore, opts.CreatedAfter)
+	}
+	if !opts.ExcludeDrafts || !opts.NeedsReview || opts.MinLines != 10 || opts.MaxLines != 500 || opts.Milestone != "v2.9" {
+		t.Errorf("opts = %+v", opts)
+	}
+	if !slices.Equal(opts.Associations, []string{"FIRST_TIME_CONTRIBUTOR"}) || !slices.Equal(opts.PathPrefixes, []string{"pkg/"}) {
+		t.Errorf("associations = %v, path prefixes = %v", opts.Associations, opts.PathPrefixes)
+	}
+}
+
+func TestNewOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		repos []string
+		opts  []Option
+	}{
+		{name: "no repositories"},
+		{name: "invalid state", repos: []string{"rancher"}, opts: []Option{WithState("draft")}},
+		{name: "invalid order", repos: []string{"rancher"}, opts: []Option{WithOrder("title")}},
+		{name: "negative max PRs", repos: []string{"rancher"}, opts: []Option{WithMaxPRs(-1)}},
+		{name: "empty search query", opts: []Option{WithSearchQuery(" ")}},
+		{name: "conflicting drafts", repos: []string{"rancher"}, opts: []Option{WithExcludeDrafts(), WithOnlyDrafts()}},
+		{name: "inverted lines", repos: []string{"rancher"}, opts: []Option{WithLines(100, 10)}},
+		{name: "invalid association", repos: []string{"rancher"}, opts: []Option{WithAssociations("STRANGER")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewOptions("rancher", tt.repos, tt.opts...); err == nil {
+				t.Error("NewOptions() succeeded, want an error")
+			}
+		})
+	}
+}
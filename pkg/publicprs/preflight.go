@@ -0,0 +1,140 @@
+package publicprs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/machinebox/graphql"
+)
+
+// Viewer returns the login of the account the client authenticates as, along with the GraphQL
+// points left in the current rate limit window.  The login is empty for GitHub App installation
+// tokens, which don't act as a user.
+func Viewer(ctx context.Context, client *Client) (login string, remaining int, err error) {
+	req := graphql.NewRequest(`
+		query {
+			viewer {
+				login
+			}
+			rateLimit {
+				remaining
+			}
+		}
+	`)
+
+	var resp struct {
+		Viewer *struct {
+			Login string
+		}
+		RateLimit *struct {
+			Remaining int
+		}
+	}
+	// Installation tokens cannot read the viewer, but still answer the rate limit
+	if err := client.runPartial(ctx, req, &resp); err != nil {
+		return "", 0, fmt.Errorf("error fetching the authenticated account: %w", err)
+	}
+	if resp.RateLimit == nil {
+		return "", 0, fmt.Errorf("error fetching the authenticated account: no rate limit returned")
+	}
+	if resp.Viewer != nil {
+		login = resp.Viewer.Login
+	}
+	return login, resp.RateLimit.Remaining, nil
+}
+
+// CheckRepository returns an error when GitHub cannot resolve the repository, because it doesn't
+// exist or the token cannot read it.
+func CheckRepository(ctx context.Context, client *Client, owner, repo string) error {
+	req := graphql.NewRequest(`
+		query($owner: String!, $repo: String!) {
+			repository(owner: $owner, name: $repo) {
+				id
+			}
+		}
+	`)
+	req.Var("owner", owner)
+	req.Var("repo", repo)
+
+	var resp struct {
+		Repository *struct {
+			ID string
+		}
+	}
+	if err := client.run(ctx, req, &resp); err != nil {
+		return fmt.Errorf("%w: %w", errRepositoryUnavailable, err)
+	}
+	if resp.Repository == nil {
+		return errRepositoryUnavailable
+	}
+	return nil
+}
+
+// CheckMembershipSource returns an error when the organization, or the team when source has the form
+// org/team-slug, cannot be found with the client's token.
+func CheckMembershipSource(ctx context.Context, client *Client, source string) error {
+	org, team, isTeam := strings.Cut(source, "/")
+	req := graphql.NewRequest(`
+		query($org: String!, $team: String!, $isTeam: Boolean!) {
+			organization(login: $org) {
+				login
+				team(slug: $team) @include(if: $isTeam) {
+					slug
+				}
+			}
+		}
+	`)
+	req.Var("org", org)
+	req.Var("team", team)
+	req.Var("isTeam", isTeam)
+
+	var resp struct {
+		Organization *struct {
+			Login string
+			Team  *struct {
+				Slug string
+			}
+		}
+	}
+	if err := client.run(ctx, req, &resp); err != nil {
+		return fmt.Errorf("error fetching organization %s: %w", org, err)
+	}
+	if resp.Organization == nil {
+		return fmt.Errorf("organization %s not found", org)
+	}
+	if isTeam && resp.Organization.Team == nil {
+		return fmt.Errorf("team %s not found in organization %s, or not visible to the token", team, org)
+	}
+	return nil
+}
+
+// ProjectV2Access returns the title of the project with the given global ID and whether the token
+// can update it, as needed to add or remove items.
+func ProjectV2Access(ctx context.Context, client *Client, projectID string) (title string, canUpdate bool, err error) {
+	req := graphql.NewRequest(`
+		query($projectID: ID!) {
+			node(id: $projectID) {
+				... on ProjectV2 {
+					title
+					viewerCanUpdate
+				}
+			}
+		}
+	`)
+	req.Var("projectID", projectID)
+
+	var resp struct {
+		Node *struct {
+			Title           string
+			ViewerCanUpdate bool
+		}
+	}
+	if err := client.run(ctx, req, &resp); err != nil {
+		return "", false, fmt.Errorf("error fetching project: %w", err)
+	}
+	if resp.Node == nil {
+		return "", false, fmt.Errorf("%w: %s", ErrProjectNotFound, projectID)
+	}
+	return resp.Node.Title, resp.Node.ViewerCanUpdate, nil
+}
@@ -0,0 +1,104 @@
+package publicprs
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestViewer(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  interface{}
+		wantLogin string
+		wantErr   bool
+	}{
+		{
+			name:      "user token",
+			response:  map[string]interface{}{"viewer": map[string]interface{}{"login": "jdoe"}, "rateLimit": map[string]interface{}{"remaining": 4990}},
+			wantLogin: "jdoe",
+		},
+		{
+			name: "installation token",
+			response: graphqlResponse{
+				Data:   map[string]interface{}{"viewer": nil, "rateLimit": map[string]interface{}{"remaining": 4990}},
+				Errors: []map[string]interface{}{{"message": "Resource not accessible by integration"}},
+			},
+		},
+		{
+			name:     "bad credentials",
+			response: graphqlResponse{Errors: []map[string]interface{}{{"message": "Bad credentials"}}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(req graphqlRequest) interface{} { return tt.response }, nil)
+			login, remaining, err := Viewer(context.Background(), client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Viewer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (login != tt.wantLogin || remaining != 4990) {
+				t.Errorf("Viewer() = %q, %d", login, remaining)
+			}
+		})
+	}
+}
+
+func TestCheckRepository(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if req.Variables["repo"] == "missing" {
+			return graphqlResponse{
+				Data:   map[string]interface{}{"repository": nil},
+				Errors: []map[string]interface{}{{"message": "Could not resolve to a Repository with the name 'rancher/missing'."}},
+			}
+		}
+		return map[string]interface{}{"repository": map[string]interface{}{"id": "R_1"}}
+	}, nil)
+
+	if err := CheckRepository(context.Background(), client, "rancher", "rancher"); err != nil {
+		t.Errorf("CheckRepository() error = %v", err)
+	}
+	if err := CheckRepository(context.Background(), client, "rancher", "missing"); !errors.Is(err, errRepositoryUnavailable) {
+		t.Errorf("CheckRepository() error = %v, want errRepositoryUnavailable", err)
+	}
+}
+
+func TestCheckMembershipSource(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if req.Variables["org"] != "rancher" {
+			return map[string]interface{}{"organization": nil}
+		}
+		var team interface{}
+		if req.Variables["team"] == "core" {
+			team = map[string]interface{}{"slug": "core"}
+		}
+		return map[string]interface{}{"organization": map[string]interface{}{"login": "rancher", "team": team}}
+	}, nil)
+
+	tests := []struct {
+		source  string
+		wantErr bool
+	}{
+		{source: "rancher"},
+		{source: "rancher/core"},
+		{source: "rancher/ghosts", wantErr: true},
+		{source: "nowhere", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckMembershipSource(context.Background(), client, tt.source); (err != nil) != tt.wantErr {
+			t.Errorf("CheckMembershipSource(%q) error = %v, wantErr %v", tt.source, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProjectV2Access(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		return map[string]interface{}{"node": map[string]interface{}{"title": "External PRs", "viewerCanUpdate": false}}
+	}, nil)
+
+	title, canUpdate, err := ProjectV2Access(context.Background(), client, "PVT_project")
+	if err != nil || title != "External PRs" || canUpdate {
+		t.Errorf("ProjectV2Access() = %q, %v, %v", title, canUpdate, err)
+	}
+}
@@ -0,0 +1,511 @@
+package publicprs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/machinebox/graphql"
+)
+
+// GetProjectV2ID fetches the global ID for the ProjectV2.  The project is looked up under the
+// organization with the given login first, then under the user with that login.
+func GetProjectV2ID(ctx context.Context, client *Client, owner string, projectNumber int) (string, error) {
+	id, orgErr := getOwnerProjectV2ID(ctx, client, "organization", owner, projectNumber)
+	if orgErr == nil && id != "" {
+		return id, nil
+	}
+
+	id, userErr := getOwnerProjectV2ID(ctx, client, "user", owner, projectNumber)
+	if userErr == nil && id != "" {
+		return id, nil
+	}
+
+	err := fmt.Errorf("%w: #%d of organization or user %s", ErrProjectNotFound, projectNumber, owner)
+	if orgErr != nil || userErr != nil {
+		err = fmt.Errorf("%w: %w", err, errors.Join(orgErr, userErr))
+	}
+	return "", err
+}
+
+// getOwnerProjectV2ID fetches the global ID for a ProjectV2 owned by an organization or a user,
+// depending on ownerType.  An empty ID is returned when the owner has no such project.
+func getOwnerProjectV2ID(ctx context.Context, client *Client, ownerType string, login string, projectNumber int) (string, error) {
+	req := graphql.NewRequest(fmt.Sprintf(`
+		query($login: String!, $projectNumber: Int!) {
+			%s(login: $login) {
+				projectV2(number: $projectNumber) {
+					id
+				}
+			}
+		}
+	`, ownerType))
+	req.Var("login", login)
+	req.Var("projectNumber", projectNumber)
+
+	var resp map[string]*struct {
+		ProjectV2 *struct {
+			ID string `json:"id"`
+		} `json:"projectV2"`
+	}
+
+	if err := client.run(ctx, req, &resp); err != nil {
+		return "", fmt.Errorf("error fetching %s project ID: %w", ownerType, err)
+	}
+
+	owner := resp[ownerType]
+	if owner == nil || owner.ProjectV2 == nil {
+		return "", nil
+	}
+	return owner.ProjectV2.ID, nil
+}
+
+// AddPRToProject fetches the global ID of the PR and adds it to the specified project using the global ID.
+// inProject is the set of content IDs already in the project, as returned by ProjectContentIDs; PRs found
+// in it are not added again, and PRs that get added are recorded in it.
+// The ID of the new project item is returned, or an empty ID when the PR was already in the project.
+// PRs fetched by FetchPullRequests already carry their global ID; add them with AddContentToProject.
+func AddPRToProject(ctx context.Context, client *Client, projectID string, owner string, repo string, prNumber int, inProject map[string]bool) (string, error) {
+	// Fetch the global ID of the PR
+	prID, err := GetPullRequestID(ctx, client, owner, repo, prNumber)
+	if err != nil {
+		return "", fmt.Errorf("error fetching global ID for PR #%d: %w", prNumber, err)
+	}
+	return AddContentToProject(ctx, client, projectID, prID, inProject)
+}
+
+// AddContentToProject adds the PR or issue with the given global ID to the specified project, unless
+// it is already in inProject, the set of content IDs in the project, which records it once added.
+// The ID of the new project item is returned, or an empty ID when it was already in the project.
+func AddContentToProject(ctx context.Context, client *Client, projectID string, prID string, inProject map[string]bool) (string, error) {
+	// Check if the PR is already in the project
+	if inProject[prID] {
+		return "", nil
+	}
+
+	itemID, err := AddProjectItem(ctx, client, projectID, prID)
+	if errors.Is(err, ErrAlreadyInProject) {
+		inProject[prID] = true
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	inProject[prID] = true
+
+	return itemID, nil
+}
+
+// Errors wrapped by AddProjectItem when GitHub rejects an addition for a known reason, so callers can
+// report it and carry on with the remaining content.
+var (
+	// ErrAlreadyInProject is returned when the content is already an item of the project.
+	ErrAlreadyInProject = errors.New("content already in project")
+	// ErrProjectArchived is returned when the project is closed or archived and cannot be changed.
+	ErrProjectArchived = errors.New("project is archived")
+	// ErrContentNotFound is returned when the project or the content no longer exists, or the token
+	// cannot see it.
+	ErrContentNotFound = errors.New("project or content not found")
+)
+
+// AddProjectItem adds the PR or issue with the given global ID to the specified project and returns
+// the ID of its project item.  GitHub returns the existing item when the content is already in the
+// project, so adding it twice is harmless.  Unlike AddContentToProject it keeps no record of the
+// project's content, leaving callers that add concurrently to guard their own.  Errors GitHub
+// reports for content already in the project, an archived project or a missing project or content
+// wrap ErrAlreadyInProject, ErrProjectArchived or ErrContentNotFound.
+func AddProjectItem(ctx context.Context, client *Client, projectID string, prID string) (string, error) {
+	req := graphql.NewRequest(`
+		mutation($projectID: ID!, $prID: ID!) {
+			addProjectV2ItemById(input: {projectId: $projectID, contentId: $prID}) {
+				item {
+					id
+				}
+			}
+		}
+	`)
+
+	req.Var("projectID", projectID)
+	req.Var("prID", prID)
+
+	var mutationResp struct {
+		AddProjectV2ItemById struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+
+	if err := client.run(ctx, req, &mutationResp); err != nil {
+		return "", fmt.Errorf("error adding PR to project: %w", classifyAddError(err))
+	}
+
+	return mutationResp.AddProjectV2ItemById.Item.ID, nil
+}
+
+// classifyAddError wraps an error GitHub returned for addProjectV2ItemById with the error of
+// AddProjectItem matching its message, if any.  GitHub reports these cases by message only.
+func classifyAddError(err error) error {
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "already exists"), strings.Contains(message, "already been added"):
+		return fmt.Errorf("%w: %w", ErrAlreadyInProject, err)
+	case strings.Contains(message, "archived"), strings.Contains(message, "closed project"):
+		return fmt.Errorf("%w: %w", ErrProjectArchived, err)
+	case strings.Contains(message, "not found"), strings.Contains(message, "could not resolve"):
+		return fmt.Errorf("%w: %w", ErrContentNotFound, err)
+	}
+	return err
+}
+
+// PRInProject reports whether the PR with the given number is in inProject, the set of content IDs
+// returned by ProjectContentIDs.
+func PRInProject(ctx context.Context, client *Client, owner string, repo string, prNumber int, inProject map[string]bool) (bool, error) {
+	prID, err := GetPullRequestID(ctx, client, owner, repo, prNumber)
+	if err != nil {
+		return false, fmt.Errorf("error fetching global ID for PR #%d: %w", prNumber, err)
+	}
+
+	return inProject[prID], nil
+}
+
+// ResolvePullRequestID returns the global ID of pr, which PRs fetched by FetchPullRequests carry,
+// fetching it by number for PRs built without it.
+func ResolvePullRequestID(ctx context.Context, client *Client, pr PullRequest) (string, error) {
+	if pr.ID != "" {
+		return pr.ID, nil
+	}
+	id, err := GetPullRequestID(ctx, client, pr.Owner, pr.Repo, pr.Number)
+	if err != nil {
+		return "", fmt.Errorf("error fetching global ID for PR #%d: %w", pr.Number, err)
+	}
+	return id, nil
+}
+
+// GetPullRequestID fetches the global ID for a given PR by its number
+func GetPullRequestID(ctx context.Context, client *Client, owner string, repo string, prNumber int) (string, error) {
+	req := graphql.NewRequest(`
+		query($owner: String!, $repo: String!, $prNumber: Int!) {
+			repository(owner: $owner, name: $repo) {
+				pullRequest(number: $prNumber) {
+					id
+				}
+			}
+			rateLimit {
+				cost
+				remaining
+			}
+		}
+	`)
+
+	req.Var("owner", owner)
+	req.Var("repo", repo)
+	req.Var("prNumber", prNumber)
+
+	var resp struct {
+		Repository struct {
+			PullRequest struct {
+				ID string `json:"id"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+
+	if err := client.run(ctx, req, &resp); err != nil {
+		return "", fmt.Errorf("error fetching PR ID: %w", err)
+	}
+
+	return resp.Repository.PullRequest.ID, nil
+}
+
+// CheckPRInProject checks if a pull request is already in the specified project, paging through
+// the project's items until it is found.  To check many PRs, load the project once with
+// ProjectContentIDs instead.
+func CheckPRInProject(ctx context.Context, client *Client, projectID, prID string) (bool, error) {
+	found := false
+	_, err := projectItems(ctx, client, projectID, func(item ProjectItem) bool {
+		found = item.ContentID == prID
+		return found
+	})
+	if err != nil {
+		return false, fmt.Errorf("error checking PR in project: %w", err)
+	}
+	return found, nil
+}
+
+// ProjectContentIDs returns the global IDs of the content (PRs and issues) of every item in the
+// specified project, paging through all of the project's items.
+func ProjectContentIDs(ctx context.Context, client *Client, projectID string) (map[string]bool, error) {
+	items, err := projectItems(ctx, client, projectID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ContentIDs(items), nil
+}
+
+// ContentIDs returns the set of content IDs of items, in the form expected by AddPRToProject,
+// AddContentToProject and PRInProject.
+func ContentIDs(items []ProjectItem) map[string]bool {
+	contentIDs := make(map[string]bool, len(items))
+	for _, item := range items {
+		contentIDs[item.ContentID] = true
+	}
+	return contentIDs
+}
+
+// ProjectItem is an item of a GitHub project whose content is a pull request or an issue.
+type ProjectItem struct {
+	// ID is the global ID of the project item.
+	ID string
+	// ContentID is the global ID of the pull request or issue.
+	ContentID string
+	// Type is the GraphQL type of the content, PullRequest or Issue.
+	Type string
+	// Owner is the login of the owner of the content's repository, which may differ from the
+	// project's owner.
+	Owner  string
+	Repo   string
+	Number int
+	Author string
+	// FieldValues maps the names of the item's single select fields that are set to the names of
+	// their options, such as Status to Needs Triage.
+	FieldValues map[string]string
+}
+
+// FieldValue returns the option set in the item's single select field with the given name, compared
+// case-insensitively, or an empty string when the field is not set.
+func (item ProjectItem) FieldValue(name string) string {
+	for field, value := range item.FieldValues {
+		if strings.EqualFold(field, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// ProjectPRItems returns every pull request item of the specified project, paging through all of
+// the project's items.  Items whose content is not a pull request are left out.
+func ProjectPRItems(ctx context.Context, client *Client, projectID string) ([]ProjectItem, error) {
+	items, err := projectItems(ctx, client, projectID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return PRItems(items), nil
+}
+
+// ProjectItems returns every item of the specified project whose content is a pull request or an
+// issue, paging through all of the project's items.
+func ProjectItems(ctx context.Context, client *Client, projectID string) ([]ProjectItem, error) {
+	return projectItems(ctx, client, projectID, nil)
+}
+
+// PRItems returns the items of items whose content is a pull request.
+func PRItems(items []ProjectItem) []ProjectItem {
+	var prItems []ProjectItem
+	for _, item := range items {
+		if item.Type == "PullRequest" {
+			prItems = append(prItems, item)
+		}
+	}
+	return prItems
+}
+
+// projectItems returns every item of the specified project whose content is a pull request or an
+// issue, following the items' pages until hasNextPage is false.  Draft issues and redacted items
+// have no content and are left out.  The values of the first 20 fields of each item are fetched
+// along with its content, of which the single select values are kept.  When until is set, paging
+// stops after the page holding the first item it returns true for.
+func projectItems(ctx context.Context, client *Client, projectID string, until func(ProjectItem) bool) ([]ProjectItem, error) {
+	var items []ProjectItem
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		req := graphql.NewRequest(`
+			query($projectID: ID!, $cursor: String) {
+				node(id: $projectID) {
+					... on ProjectV2 {
+						items(first: 100, after: $cursor) {
+							nodes {
+								id
+								fieldValues(first: 20) {
+									nodes {
+										... on ProjectV2ItemFieldSingleSelectValue {
+											name
+											field {
+												... on ProjectV2FieldCommon {
+													name
+												}
+											}
+										}
+									}
+								}
+								content {
+									__typename
+									... on PullRequest {
+										id
+										number
+										repository {
+											name
+											owner {
+												login
+											}
+										}
+										author {
+											login
+										}
+									}
+									... on Issue {
+										id
+										number
+										repository {
+											name
+											owner {
+												login
+											}
+										}
+										author {
+											login
+										}
+									}
+								}
+							}
+							pageInfo {
+								endCursor
+								hasNextPage
+							}
+						}
+					}
+				}
+				rateLimit {
+					cost
+					remaining
+					resetAt
+				}
+			}
+		`)
+
+		req.Var("projectID", projectID)
+		req.Var("cursor", cursor)
+
+		var resp struct {
+			Node *struct {
+				Items struct {
+					Nodes []struct {
+						ID          string
+						FieldValues struct {
+							Nodes []struct {
+								Name  string
+								Field struct {
+									Name string
+								}
+							}
+						}
+						Content struct {
+							Typename   string `json:"__typename"`
+							ID         string
+							Number     int
+							Repository struct {
+								Name  string
+								Owner struct {
+									Login string
+								}
+							}
+							Author struct {
+								Login string
+							}
+						}
+					}
+					PageInfo struct {
+						EndCursor   string
+						HasNextPage bool
+					}
+				}
+			}
+			RateLimit rateLimit
+		}
+
+		// Items whose content the token cannot read, such as PRs of private repositories, come back
+		// as null alongside an error and are skipped
+		if err := client.runPartial(ctx, req, &resp); err != nil {
+			return nil, fmt.Errorf("error fetching project items: %w", err)
+		}
+		if resp.Node == nil {
+			return nil, fmt.Errorf("error fetching project items: %w: %s", ErrProjectNotFound, projectID)
+		}
+
+		slog.Debug("Fetched project items page", "count", len(resp.Node.Items.Nodes))
+
+		pageStart := len(items)
+		for _, item := range resp.Node.Items.Nodes {
+			if item.Content.ID == "" {
+				continue
+			}
+			var fieldValues map[string]string
+			for _, value := range item.FieldValues.Nodes {
+				// Values of other field types decode as empty objects
+				if value.Field.Name == "" {
+					continue
+				}
+				if fieldValues == nil {
+					fieldValues = make(map[string]string)
+				}
+				fieldValues[value.Field.Name] = value.Name
+			}
+			items = append(items, ProjectItem{
+				ID:          item.ID,
+				ContentID:   item.Content.ID,
+				Type:        item.Content.Typename,
+				Owner:       item.Content.Repository.Owner.Login,
+				Repo:        item.Content.Repository.Name,
+				Number:      item.Content.Number,
+				Author:      item.Content.Author.Login,
+				FieldValues: fieldValues,
+			})
+		}
+
+		if err := client.checkRateLimit(ctx, "GraphQL", resp.RateLimit.Remaining, resp.RateLimit.ResetAt); err != nil {
+			return nil, err
+		}
+
+		if until != nil && slices.ContainsFunc(items[pageStart:], until) {
+			break
+		}
+		if !resp.Node.Items.PageInfo.HasNextPage {
+			break
+		}
+		cursor = resp.Node.Items.PageInfo.EndCursor
+	}
+
+	return items, nil
+}
+
+// DeleteProjectItem removes an item from the specified project.
+func DeleteProjectItem(ctx context.Context, client *Client, projectID, itemID string) error {
+	req := graphql.NewRequest(`
+		mutation($projectID: ID!, $itemID: ID!) {
+			deleteProjectV2Item(input: {projectId: $projectID, itemId: $itemID}) {
+				deletedItemId
+			}
+		}
+	`)
+
+	req.Var("projectID", projectID)
+	req.Var("itemID", itemID)
+
+	var resp struct {
+		DeleteProjectV2Item struct {
+			DeletedItemID string `json:"deletedItemId"`
+		} `json:"deleteProjectV2Item"`
+	}
+
+	if err := client.run(ctx, req, &resp); err != nil {
+		return fmt.Errorf("error deleting item from project: %w", err)
+	}
+
+	return nil
+}
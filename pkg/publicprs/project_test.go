@@ -0,0 +1,300 @@
+package publicprs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// projectItemsPage builds a page of project items whose content IDs are pr<start>..pr<start+count-1>.
+// Every tenth item is an issue.
+func projectItemsPage(start, count int, hasNextPage bool) interface{} {
+	var nodes []interface{}
+	for i := start; i < start+count; i++ {
+		typename := "PullRequest"
+		if i%10 == 9 {
+			typename = "Issue"
+		}
+		status := "Needs Triage"
+		if i%2 == 1 {
+			status = "In Progress"
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"id": fmt.Sprintf("item%d", i),
+			"fieldValues": map[string]interface{}{"nodes": []interface{}{
+				map[string]interface{}{},
+				map[string]interface{}{"name": status, "field": map[string]interface{}{"name": "Status"}},
+			}},
+			"content": map[string]interface{}{
+				"__typename": typename,
+				"id":         fmt.Sprintf("pr%d", i),
+				"number":     i,
+				"repository": map[string]interface{}{"name": "rancher", "owner": map[string]interface{}{"login": "rancher"}},
+				"author":     map[string]interface{}{"login": fmt.Sprintf("user%d", i)},
+			},
+		})
+	}
+	return map[string]interface{}{
+		"node": map[string]interface{}{
+			"items": map[string]interface{}{
+				"nodes": nodes,
+				"pageInfo": map[string]interface{}{
+					"endCursor":   fmt.Sprintf("cursor%d", start+count),
+					"hasNextPage": hasNextPage,
+				},
+			},
+		},
+	}
+}
+
+func TestCheckPRInProject(t *testing.T) {
+	tests := []struct {
+		name      string
+		prID      string
+		want      bool
+		wantPages int
+	}{
+		{name: "on first page", prID: "pr5", want: true, wantPages: 1},
+		{name: "on third page", prID: "pr250", want: true, wantPages: 3},
+		{name: "not in project", prID: "pr999", want: false, wantPages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+			client := newTestClient(t, threePageProjectHandler(t, &pages), nil)
+
+			got, err := CheckPRInProject(context.Background(), client, "project", tt.prID)
+			if err != nil {
+				t.Fatalf("CheckPRInProject() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CheckPRInProject() = %v, want %v", got, tt.want)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("fetched %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+// threePageProjectHandler serves a project with 260 items spread over three pages, counting the
+// pages requested.
+func threePageProjectHandler(t *testing.T, pages *int) func(graphqlRequest) interface{} {
+	return func(req graphqlRequest) interface{} {
+		*pages++
+		switch req.Variables["cursor"] {
+		case "":
+			return projectItemsPage(0, 100, true)
+		case "cursor100":
+			return projectItemsPage(100, 100, true)
+		case "cursor200":
+			return projectItemsPage(200, 60, false)
+		}
+		t.Errorf("unexpected cursor %v", req.Variables["cursor"])
+		return nil
+	}
+}
+
+func TestProjectItems(t *testing.T) {
+	pages := 0
+	client := newTestClient(t, threePageProjectHandler(t, &pages), nil)
+
+	items, err := projectItems(context.Background(), client, "project", nil)
+	if err != nil {
+		t.Fatalf("projectItems() error = %v", err)
+	}
+	if pages != 3 {
+		t.Errorf("fetched %d pages, want 3", pages)
+	}
+	if len(items) != 260 {
+		t.Fatalf("got %d items, want 260", len(items))
+	}
+	last := items[259]
+	want := ProjectItem{ID: "item259", ContentID: "pr259", Type: "Issue", Owner: "rancher", Repo: "rancher", Number: 259, Author: "user259", FieldValues: map[string]string{"Status": "In Progress"}}
+	if !reflect.DeepEqual(last, want) {
+		t.Errorf("last item = %+v, want %+v", last, want)
+	}
+	if status := last.FieldValue("status"); status != "In Progress" {
+		t.Errorf("FieldValue(status) = %q, want In Progress", status)
+	}
+	if value := last.FieldValue("Priority"); value != "" {
+		t.Errorf("FieldValue(Priority) = %q, want none", value)
+	}
+
+	pages = 0
+	prItems, err := ProjectPRItems(context.Background(), client, "project")
+	if err != nil {
+		t.Fatalf("ProjectPRItems() error = %v", err)
+	}
+	if len(prItems) != 234 {
+		t.Errorf("got %d PR items, want 234", len(prItems))
+	}
+}
+
+func TestProjectContentIDs(t *testing.T) {
+	pages := 0
+	client := newTestClient(t, threePageProjectHandler(t, &pages), nil)
+
+	got, err := ProjectContentIDs(context.Background(), client, "project")
+	if err != nil {
+		t.Fatalf("ProjectContentIDs() error = %v", err)
+	}
+	if len(got) != 260 {
+		t.Errorf("got %d content IDs, want 260", len(got))
+	}
+	if !got["pr250"] {
+		t.Error("content ID from the third page is missing")
+	}
+	if pages != 3 {
+		t.Errorf("fetched %d pages, want 3", pages)
+	}
+}
+
+func TestGetProjectV2ID(t *testing.T) {
+	notFound := func(ownerType string) interface{} {
+		return graphqlResponse{
+			Data:   map[string]interface{}{ownerType: nil},
+			Errors: []map[string]interface{}{{"message": "Could not resolve to a " + ownerType}},
+		}
+	}
+	project := func(ownerType string) interface{} {
+		return map[string]interface{}{ownerType: map[string]interface{}{"projectV2": map[string]interface{}{"id": ownerType + "-project"}}}
+	}
+
+	tests := []struct {
+		name    string
+		org     func() interface{}
+		user    func() interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "organization project",
+			org:  func() interface{} { return project("organization") },
+			want: "organization-project",
+		},
+		{
+			name: "user project",
+			org:  func() interface{} { return notFound("organization") },
+			user: func() interface{} { return project("user") },
+			want: "user-project",
+		},
+		{
+			name:    "missing project",
+			org:     func() interface{} { return notFound("organization") },
+			user:    func() interface{} { return map[string]interface{}{"user": map[string]interface{}{"projectV2": nil}} },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(req graphqlRequest) interface{} {
+				if strings.Contains(req.Query, "organization(") {
+					return tt.org()
+				}
+				if tt.user == nil {
+					t.Error("unexpected user lookup")
+					return nil
+				}
+				return tt.user()
+			}, nil)
+
+			got, err := GetProjectV2ID(context.Background(), client, "rancher", 79)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetProjectV2ID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetProjectV2ID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePullRequestID(t *testing.T) {
+	queries := 0
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		queries++
+		return map[string]interface{}{"repository": map[string]interface{}{"pullRequest": map[string]interface{}{"id": "PR_fetched"}}}
+	}, nil)
+
+	tests := []struct {
+		name        string
+		pr          PullRequest
+		want        string
+		wantQueries int
+	}{
+		{name: "fetched PR carries its ID", pr: PullRequest{ID: "PR_listed", Owner: "rancher", Repo: "rancher", Number: 1}, want: "PR_listed"},
+		{name: "PR without ID", pr: PullRequest{Owner: "rancher", Repo: "rancher", Number: 1}, want: "PR_fetched", wantQueries: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries = 0
+			got, err := ResolvePullRequestID(context.Background(), client, tt.pr)
+			if err != nil {
+				t.Fatalf("ResolvePullRequestID() error = %v", err)
+			}
+			if got != tt.want || queries != tt.wantQueries {
+				t.Errorf("ResolvePullRequestID() = %q after %d queries, want %q after %d", got, queries, tt.want, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestAddProjectItemErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    error
+	}{
+		{name: "already added", message: "Content already exists in this project", want: ErrAlreadyInProject},
+		{name: "archived project", message: "Project is archived and cannot be modified", want: ErrProjectArchived},
+		{name: "missing content", message: "Could not resolve to a node with the global id of 'PR_gone'", want: ErrContentNotFound},
+		{name: "other error", message: "Something went wrong while executing your query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(req graphqlRequest) interface{} {
+				return graphqlResponse{
+					Data:   map[string]interface{}{"addProjectV2ItemById": nil},
+					Errors: []map[string]interface{}{{"message": tt.message}},
+				}
+			}, nil)
+
+			_, err := AddProjectItem(context.Background(), client, "PVT_project", "PR_id")
+			if err == nil {
+				t.Fatal("AddProjectItem() succeeded, want an error")
+			}
+			for _, known := range []error{ErrAlreadyInProject, ErrProjectArchived, ErrContentNotFound} {
+				if got := errors.Is(err, known); got != (known == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, known, got)
+				}
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("error %q does not include GitHub's message", err)
+			}
+		})
+	}
+}
+
+func TestAddContentToProjectAlreadyAdded(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		return graphqlResponse{
+			Data:   map[string]interface{}{"addProjectV2ItemById": nil},
+			Errors: []map[string]interface{}{{"message": "Content already exists in this project"}},
+		}
+	}, nil)
+
+	inProject := make(map[string]bool)
+	itemID, err := AddContentToProject(context.Background(), client, "PVT_project", "PR_id", inProject)
+	if err != nil || itemID != "" {
+		t.Fatalf("AddContentToProject() = %q, %v, want an empty ID and no error", itemID, err)
+	}
+	if !inProject["PR_id"] {
+		t.Error("PR not recorded as in the project")
+	}
+}
@@ -0,0 +1,454 @@
+// Package publicprs finds pull requests opened by users outside of a set of GitHub
+// organizations and manages their membership in a GitHub project.
+package publicprs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeletedUser is the author reported for PRs whose author account has been deleted.
+const DeletedUser = "(deleted user)"
+
+// PullRequest is a pull request as reported by publicprs.
+type PullRequest struct {
+	// ID is the global node ID of the PR, used to add it to projects.
+	ID                 string        `json:"id"`
+	Owner              string        `json:"owner"`
+	Repo               string        `json:"repo"`
+	Number             int           `json:"number"`
+	Title              string        `json:"title"`
+	Body               string        `json:"body,omitempty"`
+	URL                string        `json:"url"`
+	BaseBranch         string        `json:"baseBranch"`
+	HeadOwner          string        `json:"headOwner"`
+	HeadBranch         string        `json:"headBranch"`
+	CreatedAt          time.Time     `json:"createdAt"`
+	UpdatedAt          time.Time     `json:"updatedAt"`
+	Author             string        `json:"author"`
+	AuthorIsBot        bool          `json:"authorIsBot"`
+	AuthorAssociation  string        `json:"authorAssociation"`
+	AuthorOrgs         []string      `json:"authorOrgs,omitempty"`
+	Labels             []string      `json:"labels,omitempty"`
+	IsDraft            bool          `json:"isDraft"`
+	Mergeable          string        `json:"mergeable"`
+	ChecksState        string        `json:"checksState,omitempty"`
+	State              string        `json:"state"`
+	Milestone          string        `json:"milestone,omitempty"`
+	MilestoneNumber    int           `json:"milestoneNumber,omitempty"`
+	Reviews            int           `json:"reviews"`
+	ReviewDecision     string        `json:"reviewDecision,omitempty"`
+	Assignees          []string      `json:"assignees,omitempty"`
+	RequestedReviewers []string      `json:"requestedReviewers,omitempty"`
+	LinkedIssues       []LinkedIssue `json:"linkedIssues,omitempty"`
+	Additions          int           `json:"additions"`
+	Deletions          int           `json:"deletions"`
+	// Stale is not set by this package; callers set it to flag PRs waiting for too long.
+	Stale bool `json:"stale,omitempty"`
+}
+
+// NameWithOwner returns the full name of the PR's repository, such as rancher/dashboard.
+func (pr PullRequest) NameWithOwner() string {
+	return pr.Owner + "/" + pr.Repo
+}
+
+// SplitRepo splits a repository given as owner/name, returning defaultOwner as the owner of
+// repositories given by name alone.
+func SplitRepo(defaultOwner, repo string) (owner, name string) {
+	if owner, name, ok := strings.Cut(repo, "/"); ok {
+		return owner, name
+	}
+	return defaultOwner, repo
+}
+
+// FromFork reports whether the PR's changes come from a repository owned by someone other than
+// the owner of its base repository, including forks that have since been deleted.
+func (pr PullRequest) FromFork() bool {
+	return pr.HeadOwner != pr.Owner
+}
+
+// Green reports whether the PR is ready to merge: GitHub found no conflicts and the checks of its
+// last commit passed.  A PR whose mergeability GitHub is still computing, reported as UNKNOWN,
+// is not green.
+func (pr PullRequest) Green() bool {
+	return pr.Mergeable == "MERGEABLE" && pr.ChecksState == "SUCCESS"
+}
+
+// InMilestone reports whether the PR is assigned to the milestone with the given title, or number
+// when milestone is a number.
+func (pr PullRequest) InMilestone(milestone string) bool {
+	if pr.Milestone == "" {
+		return false
+	}
+	return pr.Milestone == milestone || strconv.Itoa(pr.MilestoneNumber) == milestone
+}
+
+// Lines returns the size of the PR's diff, the number of lines added plus the number deleted.
+func (pr PullRequest) Lines() int {
+	return pr.Additions + pr.Deletions
+}
+
+// LinkedIssue is an issue that a pull request will close when merged.
+type LinkedIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
+// ProgressFunc is called by FetchExternalPRs after each page of PRs is fetched.  fetched is the
+// number of PRs fetched so far across all repositories.  total is the number of PRs fetched from
+// the previous repositories plus the number expected from the current one, so it grows as each
+// repository is started.
+type ProgressFunc func(fetched, total int)
+
+// AuthorAssociations are the values GitHub reports for the relationship of a PR's author to the
+// repository.
+var AuthorAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR", "CONTRIBUTOR", "FIRST_TIME_CONTRIBUTOR", "FIRST_TIMER", "MANNEQUIN", "NONE"}
+
+// Order reports whether pull request a sorts before b.
+type Order func(a, b PullRequest) bool
+
+// PullRequestOrder maps a sort key to the Order it names: created, updated, number or author,
+// with a leading "-" to sort in descending order.  PRs by the same author are sorted by creation date.
+func PullRequestOrder(key string) (Order, error) {
+	field, descending := strings.CutPrefix(key, "-")
+	var order Order
+	switch field {
+	case "created":
+		order = func(a, b PullRequest) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case "updated":
+		order = func(a, b PullRequest) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	case "number":
+		order = func(a, b PullRequest) bool { return a.Number < b.Number }
+	case "author":
+		order = func(a, b PullRequest) bool {
+			if a.Author != b.Author {
+				return a.Author < b.Author
+			}
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	default:
+		return nil, fmt.Errorf("invalid sort key %q: expected created, updated, number or author, optionally prefixed with -", key)
+	}
+	if descending {
+		return func(a, b PullRequest) bool { return order(b, a) }, nil
+	}
+	return order, nil
+}
+
+// Options controls which pull requests FetchExternalPRs reports.
+type Options struct {
+	// Owner is the user or organization owning the repositories.
+	Owner string
+	// Repos are the names of the repositories to scan, owned by Owner, or owner/name for
+	// repositories of other owners.
+	Repos []string
+	// SearchQuery, when set, fetches the PRs matching this GitHub search query, as defined by
+	// SearchPullRequests, instead of the PRs of Repos, and States is ignored.  The other filters
+	// still apply to the PRs found.
+	SearchQuery string
+	// States are the GraphQL states of the PRs to fetch, as returned by PullRequestStates.
+	// All states are fetched when it is empty.
+	States []string
+	// Members is the set of logins considered internal.
+	Members map[string]bool
+	// IncludeBots reports PRs authored by bot accounts and by the logins in BotsToExclude.
+	IncludeBots bool
+	// BotsToExclude lists additional bot logins, such as App accounts reported as users,
+	// that are skipped unless IncludeBots is set.
+	BotsToExclude []string
+	// Labels restricts the report to PRs carrying at least one of these labels.
+	Labels []string
+	// RequireAll requires PRs to carry all of Labels instead of any one of them.
+	RequireAll bool
+	// ExcludeDrafts skips draft PRs.
+	ExcludeDrafts bool
+	// OnlyDrafts reports draft PRs only.
+	OnlyDrafts bool
+	// NeedsReview reports only PRs without any review or review decision.
+	NeedsReview bool
+	// Unassigned reports only PRs without any assignee.
+	Unassigned bool
+	// GreenOnly reports only PRs that are ready to merge, as defined by PullRequest.Green.
+	GreenOnly bool
+	// LinkedOnly reports only PRs that close at least one issue.
+	LinkedOnly bool
+	// Milestone restricts the report to PRs assigned to the milestone with this title or number.
+	// PRs are not filtered by milestone when it is empty.
+	Milestone string
+	// MinLines and MaxLines restrict the report to PRs whose diff size, as returned by
+	// PullRequest.Lines, is within these bounds.  Zero means no bound.
+	MinLines int
+	MaxLines int
+	// CreatedAfter and CreatedBefore restrict the report to PRs created within this window.
+	// A zero time means no bound.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// UpdatedAfter and UpdatedBefore restrict the report to PRs last updated within this window.
+	// A zero time means no bound.
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	// FromForks reports only PRs whose changes come from a fork owned by someone else, as
+	// defined by PullRequest.FromFork.
+	FromForks bool
+	// BaseBranches restricts the report to PRs targeting one of these branches.
+	// PRs against any branch are reported when it is empty.
+	BaseBranches []string
+	// IncludeGhost reports PRs whose author account has been deleted, with DeletedUser as their
+	// author.  They are skipped otherwise.
+	IncludeGhost bool
+	// IncludeBody fetches the description of each PR into PullRequest.Body.  Descriptions can be
+	// long, so they are only fetched when asked for.
+	IncludeBody bool
+	// PathPrefixes restricts the report to PRs changing at least one file that matches one of these
+	// patterns, as defined by MatchesPath.  The changed files are only fetched when it is set, and
+	// only for PRs passing the other filters.
+	PathPrefixes []string
+	// Associations restricts the report to PRs whose author association, one of AuthorAssociations,
+	// is one of these, independently of Members.
+	Associations []string
+	// AuthorPattern skips PRs whose author login matches it.
+	AuthorPattern *regexp.Regexp
+	// MaxPRs stops fetching once this many PRs have been scanned across all repositories.
+	// Zero means no limit.
+	MaxPRs int
+	// Order sorts the reported PRs.  They are sorted by creation date, oldest first, when it is nil.
+	Order Order
+	// Progress, when set, is called after each page of PRs is fetched.
+	Progress ProgressFunc
+}
+
+// Stats counts the PRs seen while fetching external PRs.
+type Stats struct {
+	// Scanned is the number of PRs fetched from the repositories.
+	Scanned int
+	// External is the number of PRs reported as external.
+	External int
+	// Bots is the number of PRs from non-members skipped because they were authored by a bot.
+	Bots int
+	// Truncated is set when fetching stopped because MaxPRs was reached.
+	Truncated bool
+}
+
+// FetchExternalPRs fetches the PRs of every repository in opts, or the ones matching
+// opts.SearchQuery, and returns the ones authored by users outside of opts.Members, sorted by
+// opts.Order, along with counts of the PRs that were scanned and skipped.  When several
+// repositories are scanned, the ones GitHub cannot resolve are skipped with a warning, as long as
+// at least one of them can be read.
+func FetchExternalPRs(ctx context.Context, client *Client, opts Options) ([]PullRequest, Stats, error) {
+	var pullRequests []PullRequest
+	var stats Stats
+	collect := func(fetched []PullRequest) error {
+		for _, pr := range fetched {
+			stats.Scanned++
+			if !opts.Members[pr.Author] && isSkippedBot(pr, opts) {
+				stats.Bots++
+				continue
+			}
+			if !IsExternal(pr, opts) {
+				continue
+			}
+			if len(opts.PathPrefixes) > 0 {
+				touches, err := touchesPaths(ctx, client, pr, opts.PathPrefixes)
+				if err != nil {
+					return err
+				}
+				if !touches {
+					continue
+				}
+			}
+			pullRequests = append(pullRequests, pr)
+		}
+		return nil
+	}
+
+	repos := opts.Repos
+	if opts.SearchQuery != "" {
+		repos = nil
+		found, err := searchPullRequests(ctx, client, opts.SearchQuery, opts.MaxPRs, opts.IncludeBody, opts.Progress)
+		if err != nil {
+			return nil, stats, err
+		}
+		if err := collect(found); err != nil {
+			return nil, stats, err
+		}
+		stats.Truncated = opts.MaxPRs > 0 && len(found) >= opts.MaxPRs
+	}
+	var unavailable []string
+	for _, repo := range repos {
+		limit := 0
+		if opts.MaxPRs > 0 {
+			limit = opts.MaxPRs - stats.Scanned
+			if limit <= 0 {
+				stats.Truncated = true
+				break
+			}
+		}
+
+		var onPage func(fetched, total int)
+		if opts.Progress != nil {
+			before := stats.Scanned
+			onPage = func(fetched, total int) {
+				if limit > 0 {
+					total = min(total, limit)
+				}
+				opts.Progress(before+fetched, before+total)
+			}
+		}
+
+		owner, name := SplitRepo(opts.Owner, repo)
+		repoPRs, err := fetchPullRequests(ctx, client, owner, name, opts.States, limit, opts.IncludeBody, onPage)
+		if errors.Is(err, errRepositoryUnavailable) && len(repos) > 1 {
+			slog.Warn("Skipping repository", "repo", owner+"/"+name, "err", err)
+			unavailable = append(unavailable, repo)
+			continue
+		}
+		if err != nil {
+			return nil, stats, fmt.Errorf("error fetching PRs from %s/%s: %w", owner, name, err)
+		}
+		if err := collect(repoPRs); err != nil {
+			return nil, stats, err
+		}
+		if limit > 0 && len(repoPRs) >= limit {
+			stats.Truncated = true
+		}
+	}
+	if len(repos) > 0 && len(unavailable) == len(repos) {
+		return nil, stats, fmt.Errorf("error fetching PRs: none of the repositories %v are accessible", unavailable)
+	}
+	if stats.Truncated {
+		slog.Warn("Stopped fetching PRs after reaching the limit, the report is truncated", "maxPRs", opts.MaxPRs)
+	}
+	stats.External = len(pullRequests)
+
+	order := opts.Order
+	if order == nil {
+		order = func(a, b PullRequest) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	}
+	sort.SliceStable(pullRequests, func(i, j int) bool {
+		return order(pullRequests[i], pullRequests[j])
+	})
+
+	return pullRequests, stats, nil
+}
+
+// IsExternal reports whether pr was authored outside of opts.Members and passes every filter set
+// in opts, except PathPrefixes, which needs the PR's changed files.
+func IsExternal(pr PullRequest, opts Options) bool {
+	if _, isMember := opts.Members[pr.Author]; isMember {
+		return false
+	}
+	if isSkippedBot(pr, opts) {
+		return false
+	}
+	if pr.Author == DeletedUser && !opts.IncludeGhost {
+		return false
+	}
+	if opts.AuthorPattern != nil && opts.AuthorPattern.MatchString(pr.Author) {
+		return false
+	}
+	if len(opts.Associations) > 0 && !slices.Contains(opts.Associations, pr.AuthorAssociation) {
+		return false
+	}
+	if (opts.ExcludeDrafts && pr.IsDraft) || (opts.OnlyDrafts && !pr.IsDraft) {
+		return false
+	}
+	if opts.GreenOnly && !pr.Green() {
+		return false
+	}
+	if opts.NeedsReview && (pr.Reviews > 0 || pr.ReviewDecision != "") {
+		return false
+	}
+	if opts.Unassigned && len(pr.Assignees) > 0 {
+		return false
+	}
+	if opts.LinkedOnly && len(pr.LinkedIssues) == 0 {
+		return false
+	}
+	if opts.Milestone != "" && !pr.InMilestone(opts.Milestone) {
+		return false
+	}
+	if (opts.MinLines > 0 && pr.Lines() < opts.MinLines) || (opts.MaxLines > 0 && pr.Lines() > opts.MaxLines) {
+		return false
+	}
+	if !withinWindow(pr.CreatedAt, opts.CreatedAfter, opts.CreatedBefore) || !withinWindow(pr.UpdatedAt, opts.UpdatedAfter, opts.UpdatedBefore) {
+		return false
+	}
+	if opts.FromForks && !pr.FromFork() {
+		return false
+	}
+	if len(opts.BaseBranches) > 0 && !slices.Contains(opts.BaseBranches, pr.BaseBranch) {
+		return false
+	}
+	return HasLabels(pr.Labels, opts.Labels, opts.RequireAll)
+}
+
+// withinWindow reports whether t is not before after and is before before.  Zero bounds are ignored.
+func withinWindow(t, after, before time.Time) bool {
+	return (after.IsZero() || !t.Before(after)) && (before.IsZero() || t.Before(before))
+}
+
+// touchesPaths reports whether pr changes a file matching one of patterns.
+func touchesPaths(ctx context.Context, client *Client, pr PullRequest, patterns []string) (bool, error) {
+	files, err := FetchChangedFiles(ctx, client, pr.Owner, pr.Repo, pr.Number)
+	if err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		for _, pattern := range patterns {
+			if MatchesPath(file, pattern) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// isSkippedBot reports whether pr was authored by a bot that opts excludes from the report.
+func isSkippedBot(pr PullRequest, opts Options) bool {
+	return !opts.IncludeBots && (pr.AuthorIsBot || slices.Contains(opts.BotsToExclude, pr.Author))
+}
+
+// HasLabels reports whether prLabels satisfies the wanted labels.  With requireAll
+// every wanted label must be present, otherwise any one of them is enough.
+// An empty wanted list matches every PR.
+func HasLabels(prLabels, wanted []string, requireAll bool) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, label := range wanted {
+		found := slices.Contains(prLabels, label)
+		if requireAll && !found {
+			return false
+		}
+		if !requireAll && found {
+			return true
+		}
+	}
+	return requireAll
+}
+
+// timeFormats are the date-time formats parseTime accepts, in the order they are tried.
+var timeFormats = []string{time.RFC3339, time.RFC3339Nano}
+
+// parseTime parses the GitHub date-time format into time.Time.  A malformed value is logged as a
+// warning, with logArgs identifying where it came from, and parsed as the zero time, so a single bad
+// timestamp doesn't abort a scan.
+func parseTime(dateTime string, logArgs ...any) time.Time {
+	var err error
+	for _, format := range timeFormats {
+		var t time.Time
+		if t, err = time.Parse(format, dateTime); err == nil {
+			return t
+		}
+	}
+	slog.Warn("Ignoring invalid date-time from GitHub", append([]any{"value", dateTime, "err", err}, logArgs...)...)
+	return time.Time{}
+}
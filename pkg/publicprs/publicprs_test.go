@@ -0,0 +1,388 @@
+package publicprs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// graphqlRequest is the body of a GraphQL request as sent by the client.
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// graphqlResponse lets a GraphQL test handler return errors alongside data.
+type graphqlResponse struct {
+	Data   interface{}              `json:"data"`
+	Errors []map[string]interface{} `json:"errors,omitempty"`
+}
+
+// newTestClient starts a server answering GraphQL requests with graphqlHandler and every
+// other request with rest, and returns a Client pointed at it.
+func newTestClient(t *testing.T, graphqlHandler func(req graphqlRequest) interface{}, rest http.Handler) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding GraphQL request: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		data := graphqlHandler(req)
+		resp, ok := data.(graphqlResponse)
+		if !ok {
+			resp = graphqlResponse{Data: data}
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	if rest != nil {
+		mux.Handle("/", rest)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	// Authenticate the same way as the CLI, with an oauth2 client wrapping the retry transport
+	httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test-token"}))
+	httpClient.Transport = NewRetryTransport(httpClient.Transport, 0)
+	return NewClient(server.URL+"/graphql", server.URL, httpClient)
+}
+
+func TestIsExternal(t *testing.T) {
+	opts := Options{
+		Members:       map[string]bool{"insider": true},
+		BotsToExclude: []string{"dependabot[bot]"},
+	}
+
+	tests := []struct {
+		name   string
+		pr     PullRequest
+		modify func(*Options)
+		want   bool
+	}{
+		{
+			name: "external author",
+			pr:   PullRequest{Author: "outsider"},
+			want: true,
+		},
+		{
+			name: "org member",
+			pr:   PullRequest{Author: "insider"},
+			want: false,
+		},
+		{
+			name: "excluded bot",
+			pr:   PullRequest{Author: "dependabot[bot]"},
+			want: false,
+		},
+		{
+			name:   "excluded bot with bots included",
+			pr:     PullRequest{Author: "dependabot[bot]"},
+			modify: func(o *Options) { o.IncludeBots = true },
+			want:   true,
+		},
+		{
+			name: "bot account",
+			pr:   PullRequest{Author: "renovate", AuthorIsBot: true},
+			want: false,
+		},
+		{
+			name:   "bot account with bots included",
+			pr:     PullRequest{Author: "renovate", AuthorIsBot: true},
+			modify: func(o *Options) { o.IncludeBots = true },
+			want:   true,
+		},
+		{
+			name:   "missing label",
+			pr:     PullRequest{Author: "outsider", Labels: []string{"bug"}},
+			modify: func(o *Options) { o.Labels = []string{"needs-review"} },
+			want:   false,
+		},
+		{
+			name:   "any label matches",
+			pr:     PullRequest{Author: "outsider", Labels: []string{"bug"}},
+			modify: func(o *Options) { o.Labels = []string{"needs-review", "bug"} },
+			want:   true,
+		},
+		{
+			name: "all labels required",
+			pr:   PullRequest{Author: "outsider", Labels: []string{"bug"}},
+			modify: func(o *Options) {
+				o.Labels = []string{"needs-review", "bug"}
+				o.RequireAll = true
+			},
+			want: false,
+		},
+		{
+			name:   "author matches pattern",
+			pr:     PullRequest{Author: "renovate[bot]"},
+			modify: func(o *Options) { o.AuthorPattern = regexp.MustCompile(`\[bot\]$`) },
+			want:   false,
+		},
+		{
+			name: "author matches pattern with bots included",
+			pr:   PullRequest{Author: "renovate[bot]"},
+			modify: func(o *Options) {
+				o.AuthorPattern = regexp.MustCompile(`\[bot\]$`)
+				o.IncludeBots = true
+			},
+			want: false,
+		},
+		{
+			name:   "author does not match pattern",
+			pr:     PullRequest{Author: "outsider"},
+			modify: func(o *Options) { o.AuthorPattern = regexp.MustCompile(`\[bot\]$`) },
+			want:   true,
+		},
+		{
+			name:   "draft excluded",
+			pr:     PullRequest{Author: "outsider", IsDraft: true},
+			modify: func(o *Options) { o.ExcludeDrafts = true },
+			want:   false,
+		},
+		{
+			name:   "ready PR with only drafts",
+			pr:     PullRequest{Author: "outsider"},
+			modify: func(o *Options) { o.OnlyDrafts = true },
+			want:   false,
+		},
+		{
+			name:   "draft with only drafts",
+			pr:     PullRequest{Author: "outsider", IsDraft: true},
+			modify: func(o *Options) { o.OnlyDrafts = true },
+			want:   true,
+		},
+		{
+			name:   "unreviewed PR with needs review",
+			pr:     PullRequest{Author: "outsider", ReviewDecision: ""},
+			modify: func(o *Options) { o.NeedsReview = true },
+			want:   true,
+		},
+		{
+			name:   "reviewed PR with needs review",
+			pr:     PullRequest{Author: "outsider", Reviews: 1},
+			modify: func(o *Options) { o.NeedsReview = true },
+			want:   false,
+		},
+		{
+			name:   "PR with review decision with needs review",
+			pr:     PullRequest{Author: "outsider", ReviewDecision: "REVIEW_REQUIRED"},
+			modify: func(o *Options) { o.NeedsReview = true },
+			want:   false,
+		},
+		{
+			name:   "assigned PR with unassigned",
+			pr:     PullRequest{Author: "outsider", Assignees: []string{"maintainer"}},
+			modify: func(o *Options) { o.Unassigned = true },
+			want:   false,
+		},
+		{
+			name:   "unassigned PR with unassigned",
+			pr:     PullRequest{Author: "outsider", RequestedReviewers: []string{"maintainer"}},
+			modify: func(o *Options) { o.Unassigned = true },
+			want:   true,
+		},
+		{
+			name:   "PR without linked issue with linked only",
+			pr:     PullRequest{Author: "outsider"},
+			modify: func(o *Options) { o.LinkedOnly = true },
+			want:   false,
+		},
+		{
+			name:   "PR closing an issue with linked only",
+			pr:     PullRequest{Author: "outsider", LinkedIssues: []LinkedIssue{{Number: 12}}},
+			modify: func(o *Options) { o.LinkedOnly = true },
+			want:   true,
+		},
+		{
+			name:   "PR in milestone by title",
+			pr:     PullRequest{Author: "outsider", Milestone: "v2.9.0", MilestoneNumber: 42},
+			modify: func(o *Options) { o.Milestone = "v2.9.0" },
+			want:   true,
+		},
+		{
+			name:   "PR in milestone by number",
+			pr:     PullRequest{Author: "outsider", Milestone: "v2.9.0", MilestoneNumber: 42},
+			modify: func(o *Options) { o.Milestone = "42" },
+			want:   true,
+		},
+		{
+			name:   "PR in another milestone",
+			pr:     PullRequest{Author: "outsider", Milestone: "v2.8.5", MilestoneNumber: 41},
+			modify: func(o *Options) { o.Milestone = "v2.9.0" },
+			want:   false,
+		},
+		{
+			name:   "PR without milestone",
+			pr:     PullRequest{Author: "outsider"},
+			modify: func(o *Options) { o.Milestone = "0" },
+			want:   false,
+		},
+		{
+			name:   "PR larger than max lines",
+			pr:     PullRequest{Author: "outsider", Additions: 400, Deletions: 200},
+			modify: func(o *Options) { o.MaxLines = 500 },
+			want:   false,
+		},
+		{
+			name:   "PR within size bounds",
+			pr:     PullRequest{Author: "outsider", Additions: 40, Deletions: 2},
+			modify: func(o *Options) { o.MinLines, o.MaxLines = 5, 500 },
+			want:   true,
+		},
+		{
+			name:   "PR smaller than min lines",
+			pr:     PullRequest{Author: "outsider", Additions: 1, Deletions: 1},
+			modify: func(o *Options) { o.MinLines = 5 },
+			want:   false,
+		},
+		{
+			name:   "PR created before the window",
+			pr:     PullRequest{Author: "outsider", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			modify: func(o *Options) { o.CreatedAfter = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC) },
+			want:   false,
+		},
+		{
+			name: "PR created within the window",
+			pr:   PullRequest{Author: "outsider", CreatedAt: time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)},
+			modify: func(o *Options) {
+				o.CreatedAfter, o.CreatedBefore = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+			},
+			want: true,
+		},
+		{
+			name:   "PR younger than the minimum age",
+			pr:     PullRequest{Author: "outsider", CreatedAt: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+			modify: func(o *Options) { o.CreatedBefore = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC) },
+			want:   false,
+		},
+		{
+			name: "recently updated PR with update window",
+			pr:   PullRequest{Author: "outsider", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)},
+			modify: func(o *Options) {
+				o.UpdatedAfter = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+			},
+			want: true,
+		},
+		{
+			name:   "first time contributor with association filter",
+			pr:     PullRequest{Author: "outsider", AuthorAssociation: "FIRST_TIME_CONTRIBUTOR"},
+			modify: func(o *Options) { o.Associations = []string{"FIRST_TIME_CONTRIBUTOR", "FIRST_TIMER"} },
+			want:   true,
+		},
+		{
+			name:   "returning contributor with association filter",
+			pr:     PullRequest{Author: "outsider", AuthorAssociation: "CONTRIBUTOR"},
+			modify: func(o *Options) { o.Associations = []string{"FIRST_TIME_CONTRIBUTOR"} },
+			want:   false,
+		},
+		{
+			name:   "PR from a fork with from forks",
+			pr:     PullRequest{Author: "outsider", Owner: "rancher", HeadOwner: "outsider"},
+			modify: func(o *Options) { o.FromForks = true },
+			want:   true,
+		},
+		{
+			name:   "PR from a branch of the repository with from forks",
+			pr:     PullRequest{Author: "outsider", Owner: "rancher", HeadOwner: "rancher"},
+			modify: func(o *Options) { o.FromForks = true },
+			want:   false,
+		},
+		{
+			name:   "green PR with green only",
+			pr:     PullRequest{Author: "outsider", Mergeable: "MERGEABLE", ChecksState: "SUCCESS"},
+			modify: func(o *Options) { o.GreenOnly = true },
+			want:   true,
+		},
+		{
+			name:   "PR with unknown mergeability with green only",
+			pr:     PullRequest{Author: "outsider", Mergeable: "UNKNOWN", ChecksState: "SUCCESS"},
+			modify: func(o *Options) { o.GreenOnly = true },
+			want:   false,
+		},
+		{
+			name:   "PR with failing checks with green only",
+			pr:     PullRequest{Author: "outsider", Mergeable: "MERGEABLE", ChecksState: "FAILURE"},
+			modify: func(o *Options) { o.GreenOnly = true },
+			want:   false,
+		},
+		{
+			name:   "base branch matches",
+			pr:     PullRequest{Author: "outsider", BaseBranch: "release/v2.8"},
+			modify: func(o *Options) { o.BaseBranches = []string{"main", "release/v2.8"} },
+			want:   true,
+		},
+		{
+			name:   "base branch does not match",
+			pr:     PullRequest{Author: "outsider", BaseBranch: "main"},
+			modify: func(o *Options) { o.BaseBranches = []string{"release/v2.8"} },
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := opts
+			if tt.modify != nil {
+				tt.modify(&o)
+			}
+			if got := IsExternal(tt.pr, o); got != tt.want {
+				t.Errorf("IsExternal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPullRequestOrder(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	prs := []PullRequest{
+		{Number: 3, Author: "bob", CreatedAt: day(1), UpdatedAt: day(5)},
+		{Number: 1, Author: "alice", CreatedAt: day(3), UpdatedAt: day(4)},
+		{Number: 2, Author: "bob", CreatedAt: day(2), UpdatedAt: day(6)},
+	}
+
+	tests := []struct {
+		key  string
+		want []int
+	}{
+		{key: "created", want: []int{3, 2, 1}},
+		{key: "-created", want: []int{1, 2, 3}},
+		{key: "updated", want: []int{1, 3, 2}},
+		{key: "number", want: []int{1, 2, 3}},
+		{key: "-number", want: []int{3, 2, 1}},
+		{key: "author", want: []int{1, 3, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			order, err := PullRequestOrder(tt.key)
+			if err != nil {
+				t.Fatalf("PullRequestOrder(%q) error = %v", tt.key, err)
+			}
+			sorted := slices.Clone(prs)
+			sort.SliceStable(sorted, func(i, j int) bool { return order(sorted[i], sorted[j]) })
+			var got []int
+			for _, pr := range sorted {
+				got = append(got, pr.Number)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sorted numbers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := PullRequestOrder("title"); err == nil {
+		t.Error("PullRequestOrder(\"title\") should fail")
+	}
+}
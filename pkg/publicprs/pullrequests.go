@@ -0,0 +1,549 @@
+package publicprs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/machinebox/graphql"
+)
+
+// PullRequestStates maps a state name (open, closed, merged or all) to the GraphQL pull request
+// states it covers.  All states are represented by a nil slice.
+func PullRequestStates(state string) ([]string, error) {
+	switch strings.ToLower(state) {
+	case "open":
+		return []string{"OPEN"}, nil
+	case "closed":
+		return []string{"CLOSED"}, nil
+	case "merged":
+		return []string{"MERGED"}, nil
+	case "all":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("invalid PR state %q: expected open, closed, merged or all", state)
+}
+
+// FetchPullRequests fetches the pull requests of a repository that are in one of the given
+// GraphQL states, or in any state when states is empty.  Paging stops once limit PRs have been
+// fetched; a limit of zero fetches every PR.
+func FetchPullRequests(ctx context.Context, client *Client, owner, repo string, states []string, limit int) ([]PullRequest, error) {
+	return fetchPullRequests(ctx, client, owner, repo, states, limit, false, nil)
+}
+
+// fetchPullRequests implements FetchPullRequests, calling onPage, when set, after each page with the
+// number of PRs fetched so far and the total number of PRs GitHub reports for the repository.  The
+// description of each PR is only fetched with includeBody.
+func fetchPullRequests(ctx context.Context, client *Client, owner, repo string, states []string, limit int, includeBody bool, onPage func(fetched, total int)) ([]PullRequest, error) {
+	cursor := ""
+	var pullRequests []PullRequest
+	first := client.perPage()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		req := graphql.NewRequest(`
+			query PullRequests($owner: String!, $repo: String!, $cursor: String, $states: [PullRequestState!], $first: Int!, $includeBody: Boolean!) {
+				repository(owner: $owner, name: $repo) {
+					pullRequests(first: $first, after: $cursor, states: $states) {
+						totalCount
+						nodes {
+							...PullRequestFields
+						}
+						pageInfo {
+							endCursor
+							hasNextPage
+						}
+					}
+				}
+				rateLimit {
+					cost
+					remaining
+					resetAt
+				}
+			}
+		` + pullRequestFragment)
+		req.Var("owner", owner)
+		req.Var("repo", repo)
+		req.Var("cursor", cursor)
+		req.Var("states", states)
+		req.Var("first", first)
+		req.Var("includeBody", includeBody)
+
+		var resp struct {
+			Repository *struct {
+				PullRequests struct {
+					TotalCount int
+					Nodes      []pullRequestNode
+					PageInfo   struct {
+						EndCursor   string
+						HasNextPage bool
+					}
+				}
+			}
+			RateLimit rateLimit
+		}
+
+		// Partial results are accepted so a PR with a field the token cannot read doesn't fail the
+		// whole page; those fields are left empty
+		if err := client.runPartial(ctx, req, &resp); err != nil {
+			// Heavy pages can exceed GitHub's query time limit; the same page is fetched again
+			// in smaller pieces, and the rest of the repository with the smaller size too
+			if isQueryTimeout(ctx, err) && first > minTimeoutPageSize {
+				first = max(first/2, minTimeoutPageSize)
+				slog.Warn("GitHub timed out fetching PRs, retrying with a smaller page", "repo", owner+"/"+repo, "pageSize", first, "err", err)
+				continue
+			}
+			return nil, fmt.Errorf("error fetching PRs: %w", err)
+		}
+		if resp.Repository == nil {
+			return nil, errRepositoryUnavailable
+		}
+
+		slog.Debug("Fetched PR page", "repo", owner+"/"+repo, "count", len(resp.Repository.PullRequests.Nodes))
+
+		for _, node := range resp.Repository.PullRequests.Nodes {
+			pr, err := node.toPullRequest(ctx, client, owner, repo)
+			if err != nil {
+				return nil, err
+			}
+			pullRequests = append(pullRequests, pr)
+		}
+
+		if err := client.checkRateLimit(ctx, "GraphQL", resp.RateLimit.Remaining, resp.RateLimit.ResetAt); err != nil {
+			return nil, err
+		}
+
+		if limit > 0 && len(pullRequests) >= limit {
+			pullRequests = pullRequests[:limit]
+		}
+		if onPage != nil {
+			onPage(len(pullRequests), resp.Repository.PullRequests.TotalCount)
+		}
+		if limit > 0 && len(pullRequests) >= limit {
+			return pullRequests, nil
+		}
+
+		if !resp.Repository.PullRequests.PageInfo.HasNextPage {
+			break
+		}
+		cursor = resp.Repository.PullRequests.PageInfo.EndCursor
+	}
+
+	return pullRequests, nil
+}
+
+// pullRequestFragment selects the fields of a PR decoded into pullRequestNode.  Queries using it must
+// declare the $includeBody variable.
+const pullRequestFragment = `
+	fragment PullRequestFields on PullRequest {
+		id
+		number
+		title
+		body @include(if: $includeBody)
+		url
+		baseRefName
+		headRefName
+		headRepositoryOwner {
+			login
+		}
+		createdAt
+		updatedAt
+		state
+		milestone {
+			title
+			number
+		}
+		isDraft
+		mergeable
+		commits(last: 1) {
+			nodes {
+				commit {
+					statusCheckRollup {
+						state
+					}
+				}
+			}
+		}
+		additions
+		deletions
+		reviewDecision
+		authorAssociation
+		reviews(first: 1) {
+			totalCount
+		}
+		author {
+			__typename
+			login
+		}
+		labels(first: 20) {
+			nodes {
+				name
+			}
+			pageInfo {
+				endCursor
+				hasNextPage
+			}
+		}
+		assignees(first: 10) {
+			nodes {
+				login
+			}
+		}
+		closingIssuesReferences(first: 5) {
+			nodes {
+				number
+				title
+			}
+		}
+		reviewRequests(first: 10) {
+			nodes {
+				requestedReviewer {
+					... on User {
+						login
+					}
+					... on Team {
+						combinedSlug
+					}
+				}
+			}
+		}
+	}
+`
+
+// pullRequestNode is a PR as selected by pullRequestFragment.
+type pullRequestNode struct {
+	ID                string
+	Number            int
+	Title             string
+	Body              string
+	URL               string
+	BaseRefName       string
+	HeadRefName       string
+	CreatedAt         string
+	UpdatedAt         string
+	State             string
+	IsDraft           bool
+	Mergeable         string
+	Additions         int
+	Deletions         int
+	ReviewDecision    string
+	AuthorAssociation string
+	Reviews           struct {
+		TotalCount int
+	}
+	Author *struct {
+		Typename string `json:"__typename"`
+		Login    string
+	}
+	HeadRepositoryOwner *struct {
+		Login string
+	}
+	Milestone *struct {
+		Title  string
+		Number int
+	}
+	Commits struct {
+		Nodes []struct {
+			Commit struct {
+				// The rollup is null for commits without any checks
+				StatusCheckRollup *struct {
+					State string
+				}
+			}
+		}
+	}
+	Labels    labelPage
+	Assignees struct {
+		Nodes []struct {
+			Login string
+		}
+	}
+	ClosingIssuesReferences struct {
+		Nodes []LinkedIssue
+	}
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer struct {
+				Login        string
+				CombinedSlug string
+			}
+		}
+	}
+}
+
+// toPullRequest converts the node of a PR of the owner/repo repository, fetching the labels that
+// didn't fit in the first page.
+func (pr pullRequestNode) toPullRequest(ctx context.Context, client *Client, owner, repo string) (PullRequest, error) {
+	createdAt := parseTime(pr.CreatedAt, "repo", owner+"/"+repo, "pr", pr.Number, "field", "createdAt")
+	updatedAt := parseTime(pr.UpdatedAt, "repo", owner+"/"+repo, "pr", pr.Number, "field", "updatedAt")
+	labels := pr.Labels.names()
+	// Labels are used for filtering, so a PR with more of them than the first page holds
+	// must not be judged on a partial list
+	if pr.Labels.PageInfo.HasNextPage {
+		more, err := fetchRemainingLabels(ctx, client, owner, repo, pr.Number, pr.Labels.PageInfo.EndCursor)
+		if err != nil {
+			return PullRequest{}, err
+		}
+		labels = append(labels, more...)
+	}
+	var assignees []string
+	for _, assignee := range pr.Assignees.Nodes {
+		assignees = append(assignees, assignee.Login)
+	}
+	// GitHub returns a null author once the account has been deleted
+	author, authorIsBot := DeletedUser, false
+	if pr.Author != nil && pr.Author.Login != "" {
+		author, authorIsBot = pr.Author.Login, pr.Author.Typename == "Bot"
+	}
+	// The head repository owner is null once the fork has been deleted
+	var headOwner string
+	if pr.HeadRepositoryOwner != nil {
+		headOwner = pr.HeadRepositoryOwner.Login
+	}
+	var milestone string
+	var milestoneNumber int
+	if pr.Milestone != nil {
+		milestone, milestoneNumber = pr.Milestone.Title, pr.Milestone.Number
+	}
+	var checksState string
+	if commits := pr.Commits.Nodes; len(commits) > 0 && commits[0].Commit.StatusCheckRollup != nil {
+		checksState = commits[0].Commit.StatusCheckRollup.State
+	}
+	// Reviews can be requested from users or from teams, named org/team-slug
+	var reviewers []string
+	for _, request := range pr.ReviewRequests.Nodes {
+		if reviewer := request.RequestedReviewer.Login + request.RequestedReviewer.CombinedSlug; reviewer != "" {
+			reviewers = append(reviewers, reviewer)
+		}
+	}
+	return PullRequest{
+		ID:                 pr.ID,
+		Owner:              owner,
+		Repo:               repo,
+		Number:             pr.Number,
+		Title:              pr.Title,
+		Body:               pr.Body,
+		URL:                pr.URL,
+		BaseBranch:         pr.BaseRefName,
+		HeadOwner:          headOwner,
+		HeadBranch:         pr.HeadRefName,
+		CreatedAt:          createdAt,
+		UpdatedAt:          updatedAt,
+		Author:             author,
+		AuthorIsBot:        authorIsBot,
+		AuthorAssociation:  pr.AuthorAssociation,
+		Labels:             labels,
+		IsDraft:            pr.IsDraft,
+		Mergeable:          pr.Mergeable,
+		ChecksState:        checksState,
+		State:              pr.State,
+		Milestone:          milestone,
+		MilestoneNumber:    milestoneNumber,
+		Reviews:            pr.Reviews.TotalCount,
+		ReviewDecision:     pr.ReviewDecision,
+		Assignees:          assignees,
+		RequestedReviewers: reviewers,
+		LinkedIssues:       pr.ClosingIssuesReferences.Nodes,
+		Additions:          pr.Additions,
+		Deletions:          pr.Deletions,
+	}, nil
+}
+
+// minTimeoutPageSize is the smallest page size fetchPullRequests falls back to when GitHub times out.
+const minTimeoutPageSize = 25
+
+// isQueryTimeout reports whether err is GitHub giving up on a query that took too long, or the HTTP
+// client timing out while waiting for it, rather than ctx being done.
+func isQueryTimeout(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "timeout") || strings.Contains(message, "timed out")
+}
+
+// errRepositoryUnavailable is returned by fetchPullRequests when GitHub cannot resolve the
+// repository, because it doesn't exist or the token cannot read it.
+var errRepositoryUnavailable = errors.New("repository not found or not accessible")
+
+// labelPage is a page of a pull request's labels.
+type labelPage struct {
+	Nodes []struct {
+		Name string
+	}
+	PageInfo struct {
+		EndCursor   string
+		HasNextPage bool
+	}
+}
+
+// names returns the names of the labels in the page.
+func (p labelPage) names() []string {
+	var names []string
+	for _, label := range p.Nodes {
+		names = append(names, label.Name)
+	}
+	return names
+}
+
+// fetchRemainingLabels fetches the labels of a pull request that follow cursor.
+func fetchRemainingLabels(ctx context.Context, client *Client, owner, repo string, prNumber int, cursor string) ([]string, error) {
+	var labels []string
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		req := graphql.NewRequest(`
+			query($owner: String!, $repo: String!, $prNumber: Int!, $cursor: String) {
+				repository(owner: $owner, name: $repo) {
+					pullRequest(number: $prNumber) {
+						labels(first: 100, after: $cursor) {
+							nodes {
+								name
+							}
+							pageInfo {
+								endCursor
+								hasNextPage
+							}
+						}
+					}
+				}
+				rateLimit {
+					cost
+					remaining
+					resetAt
+				}
+			}
+		`)
+		req.Var("owner", owner)
+		req.Var("repo", repo)
+		req.Var("prNumber", prNumber)
+		req.Var("cursor", cursor)
+
+		var resp struct {
+			Repository struct {
+				PullRequest struct {
+					Labels labelPage
+				}
+			}
+			RateLimit rateLimit
+		}
+
+		if err := client.run(ctx, req, &resp); err != nil {
+			return nil, fmt.Errorf("error fetching labels of PR #%d: %w", prNumber, err)
+		}
+
+		slog.Debug("Fetched PR labels page", "repo", owner+"/"+repo, "pr", prNumber, "count", len(resp.Repository.PullRequest.Labels.Nodes))
+
+		labels = append(labels, resp.Repository.PullRequest.Labels.names()...)
+		if err := client.checkRateLimit(ctx, "GraphQL", resp.RateLimit.Remaining, resp.RateLimit.ResetAt); err != nil {
+			return nil, err
+		}
+		if !resp.Repository.PullRequest.Labels.PageInfo.HasNextPage {
+			return labels, nil
+		}
+		cursor = resp.Repository.PullRequest.Labels.PageInfo.EndCursor
+	}
+}
+
+// FetchChangedFiles fetches the paths of the files changed by a pull request, paging through all
+// of them.
+func FetchChangedFiles(ctx context.Context, client *Client, owner, repo string, prNumber int) ([]string, error) {
+	cursor := ""
+	var paths []string
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		req := graphql.NewRequest(`
+			query($owner: String!, $repo: String!, $prNumber: Int!, $cursor: String) {
+				repository(owner: $owner, name: $repo) {
+					pullRequest(number: $prNumber) {
+						files(first: 100, after: $cursor) {
+							nodes {
+								path
+							}
+							pageInfo {
+								endCursor
+								hasNextPage
+							}
+						}
+					}
+				}
+				rateLimit {
+					cost
+					remaining
+					resetAt
+				}
+			}
+		`)
+		req.Var("owner", owner)
+		req.Var("repo", repo)
+		req.Var("prNumber", prNumber)
+		req.Var("cursor", cursor)
+
+		var resp struct {
+			Repository struct {
+				PullRequest struct {
+					Files struct {
+						Nodes []struct {
+							Path string
+						}
+						PageInfo struct {
+							EndCursor   string
+							HasNextPage bool
+						}
+					}
+				}
+			}
+			RateLimit rateLimit
+		}
+
+		if err := client.run(ctx, req, &resp); err != nil {
+			return nil, fmt.Errorf("error fetching files of PR #%d: %w", prNumber, err)
+		}
+
+		slog.Debug("Fetched PR files page", "repo", owner+"/"+repo, "pr", prNumber, "count", len(resp.Repository.PullRequest.Files.Nodes))
+
+		for _, file := range resp.Repository.PullRequest.Files.Nodes {
+			paths = append(paths, file.Path)
+		}
+
+		if err := client.checkRateLimit(ctx, "GraphQL", resp.RateLimit.Remaining, resp.RateLimit.ResetAt); err != nil {
+			return nil, err
+		}
+
+		if !resp.Repository.PullRequest.Files.PageInfo.HasNextPage {
+			return paths, nil
+		}
+		cursor = resp.Repository.PullRequest.Files.PageInfo.EndCursor
+	}
+}
+
+// MatchesPath reports whether file matches pattern.  A pattern ending in "**" matches every path
+// starting with the rest of the pattern, so "pkg/agent/**" matches everything under pkg/agent.
+// Other patterns containing *, ? or [ are matched with path.Match, and plain patterns are
+// treated as prefixes.
+func MatchesPath(file, pattern string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "**"); ok {
+		return strings.HasPrefix(file, prefix)
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, err := path.Match(pattern, file)
+		return err == nil && matched
+	}
+	return strings.HasPrefix(file, pattern)
+}
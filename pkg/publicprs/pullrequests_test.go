@@ -0,0 +1,392 @@
+package publicprs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pullRequestsPage builds a page of PRs numbered start..start+count-1.  The repository is reported
+// to hold 150 PRs, as served by the two pages of the tests.
+func pullRequestsPage(start, count int, hasNextPage bool) interface{} {
+	var nodes []interface{}
+	for i := start; i < start+count; i++ {
+		nodes = append(nodes, map[string]interface{}{
+			"id":                  fmt.Sprintf("PR_%d", i),
+			"number":              i,
+			"title":               fmt.Sprintf("PR %d", i),
+			"url":                 fmt.Sprintf("https://github.com/rancher/rancher/pull/%d", i),
+			"baseRefName":         "main",
+			"headRefName":         fmt.Sprintf("fix-%d", i),
+			"headRepositoryOwner": map[string]interface{}{"login": fmt.Sprintf("user%d", i)},
+			"additions":           10 * i,
+			"deletions":           i,
+			"reviews":             map[string]interface{}{"totalCount": i % 3},
+			"assignees":           map[string]interface{}{"nodes": []interface{}{map[string]interface{}{"login": "maintainer"}}},
+			"closingIssuesReferences": map[string]interface{}{"nodes": []interface{}{
+				map[string]interface{}{"number": 1000 + i, "title": fmt.Sprintf("Issue %d", 1000+i)},
+			}},
+			"reviewRequests": map[string]interface{}{"nodes": []interface{}{
+				map[string]interface{}{"requestedReviewer": map[string]interface{}{"login": "reviewer"}},
+				map[string]interface{}{"requestedReviewer": map[string]interface{}{"combinedSlug": "rancher/ui"}},
+			}},
+			"createdAt": time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC).Format(time.RFC3339),
+			"updatedAt": time.Date(2024, 2, 1, 0, 0, i, 0, time.UTC).Format(time.RFC3339),
+			"author":    map[string]interface{}{"__typename": "User", "login": fmt.Sprintf("user%d", i)},
+		})
+	}
+	return map[string]interface{}{
+		"repository": map[string]interface{}{
+			"pullRequests": map[string]interface{}{
+				"totalCount": 150,
+				"nodes":      nodes,
+				"pageInfo": map[string]interface{}{
+					"endCursor":   fmt.Sprintf("cursor%d", start+count),
+					"hasNextPage": hasNextPage,
+				},
+			},
+		},
+	}
+}
+
+func TestFetchPullRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		want      int
+		wantPages int
+	}{
+		{name: "all pages", limit: 0, want: 150, wantPages: 2},
+		{name: "limit within first page", limit: 30, want: 30, wantPages: 1},
+		{name: "limit on second page", limit: 120, want: 120, wantPages: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+			client := newTestClient(t, func(req graphqlRequest) interface{} {
+				pages++
+				if req.Variables["cursor"] == "cursor100" {
+					return pullRequestsPage(100, 50, false)
+				}
+				return pullRequestsPage(0, 100, true)
+			}, nil)
+
+			got, err := FetchPullRequests(context.Background(), client, "rancher", "rancher", []string{"OPEN"}, tt.limit)
+			if err != nil {
+				t.Fatalf("FetchPullRequests() error = %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %d PRs, want %d", len(got), tt.want)
+			}
+			if pages != tt.wantPages {
+				t.Errorf("fetched %d pages, want %d", pages, tt.wantPages)
+			}
+			if got[0].ID != "PR_0" || got[0].Repo != "rancher" || got[0].Author != "user0" || got[0].BaseBranch != "main" || got[0].CreatedAt.IsZero() || got[0].UpdatedAt.IsZero() {
+				t.Errorf("unexpected first PR %+v", got[0])
+			}
+			if !slices.Equal(got[0].Assignees, []string{"maintainer"}) || !slices.Equal(got[0].RequestedReviewers, []string{"reviewer", "rancher/ui"}) {
+				t.Errorf("assignees = %v, requested reviewers = %v", got[0].Assignees, got[0].RequestedReviewers)
+			}
+			if got[0].HeadOwner != "user0" || got[0].HeadBranch != "fix-0" || !got[0].FromFork() {
+				t.Errorf("first PR head = %s:%s", got[0].HeadOwner, got[0].HeadBranch)
+			}
+			if got[1].Additions != 10 || got[1].Deletions != 1 || got[1].Lines() != 11 {
+				t.Errorf("second PR size = +%d -%d", got[1].Additions, got[1].Deletions)
+			}
+			if want := []LinkedIssue{{Number: 1000, Title: "Issue 1000"}}; !slices.Equal(got[0].LinkedIssues, want) {
+				t.Errorf("linked issues = %v, want %v", got[0].LinkedIssues, want)
+			}
+		})
+	}
+}
+
+func TestFetchExternalPRsProgress(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if req.Variables["cursor"] == "cursor100" {
+			return pullRequestsPage(100, 50, false)
+		}
+		return pullRequestsPage(0, 100, true)
+	}, nil)
+
+	type call struct{ fetched, total int }
+	var calls []call
+	opts := Options{
+		Owner:    "rancher",
+		Repos:    []string{"rancher", "dashboard"},
+		MaxPRs:   250,
+		Progress: func(fetched, total int) { calls = append(calls, call{fetched, total}) },
+	}
+	if _, _, err := FetchExternalPRs(context.Background(), client, opts); err != nil {
+		t.Fatalf("FetchExternalPRs() error = %v", err)
+	}
+
+	want := []call{{100, 150}, {150, 150}, {250, 250}}
+	if !slices.Equal(calls, want) {
+		t.Errorf("progress calls = %v, want %v", calls, want)
+	}
+}
+
+func TestFetchExternalPRsDeletedAuthor(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		page := pullRequestsPage(1, 2, false).(map[string]interface{})
+		nodes := page["repository"].(map[string]interface{})["pullRequests"].(map[string]interface{})["nodes"].([]interface{})
+		nodes[0].(map[string]interface{})["author"] = nil
+		return page
+	}, nil)
+
+	tests := []struct {
+		name         string
+		includeGhost bool
+		want         []string
+	}{
+		{name: "skipped by default", want: []string{"user2"}},
+		{name: "included", includeGhost: true, want: []string{DeletedUser, "user2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Owner: "rancher", Repos: []string{"rancher"}, IncludeGhost: tt.includeGhost}
+			prs, _, err := FetchExternalPRs(context.Background(), client, opts)
+			if err != nil {
+				t.Fatalf("FetchExternalPRs() error = %v", err)
+			}
+			var authors []string
+			for _, pr := range prs {
+				authors = append(authors, pr.Author)
+			}
+			if !slices.Equal(authors, tt.want) {
+				t.Errorf("authors = %q, want %q", authors, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchExternalPRsIncludeBody(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		page := pullRequestsPage(1, 1, false).(map[string]interface{})
+		if req.Variables["includeBody"] == true {
+			nodes := page["repository"].(map[string]interface{})["pullRequests"].(map[string]interface{})["nodes"].([]interface{})
+			nodes[0].(map[string]interface{})["body"] = "Fixes the login page"
+		}
+		return page
+	}, nil)
+
+	for _, includeBody := range []bool{false, true} {
+		opts := Options{Owner: "rancher", Repos: []string{"rancher"}, IncludeBody: includeBody}
+		prs, _, err := FetchExternalPRs(context.Background(), client, opts)
+		if err != nil {
+			t.Fatalf("FetchExternalPRs() error = %v", err)
+		}
+		if want := map[bool]string{true: "Fixes the login page"}[includeBody]; len(prs) != 1 || prs[0].Body != want {
+			t.Errorf("IncludeBody %v: got %+v, want body %q", includeBody, prs, want)
+		}
+	}
+}
+
+func TestFetchPullRequestsShrinksPageOnTimeout(t *testing.T) {
+	var sizes []int
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		first := int(req.Variables["first"].(float64))
+		sizes = append(sizes, first)
+		if first > 50 {
+			return graphqlResponse{
+				Data:   map[string]interface{}{"repository": nil},
+				Errors: []map[string]interface{}{{"message": "Something went wrong while executing your query. This may be the result of a timeout, or it could be a GitHub bug."}},
+			}
+		}
+		start := 0
+		if cursor, _ := req.Variables["cursor"].(string); cursor != "" {
+			fmt.Sscanf(cursor, "cursor%d", &start)
+		}
+		count := min(first, 150-start)
+		return pullRequestsPage(start, count, start+count < 150)
+	}, nil)
+
+	prs, err := FetchPullRequests(context.Background(), client, "rancher", "rancher", nil, 0)
+	if err != nil {
+		t.Fatalf("FetchPullRequests() error = %v", err)
+	}
+	if len(prs) != 150 || prs[0].Number != 0 || prs[149].Number != 149 {
+		t.Errorf("got %d PRs, want all 150 in order", len(prs))
+	}
+	if !slices.Equal(sizes, []int{100, 50, 50, 50}) {
+		t.Errorf("page sizes = %v, want [100 50 50 50]", sizes)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "2024-03-05T12:00:00Z", want: time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)},
+		{value: "2024-03-05T12:00:00.123456789Z", want: time.Date(2024, 3, 5, 12, 0, 0, 123456789, time.UTC)},
+		{value: "yesterday", want: time.Time{}},
+		{value: "", want: time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := parseTime(tt.value); !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFetchExternalPRsMalformedTime(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		page := pullRequestsPage(1, 2, false).(map[string]interface{})
+		nodes := page["repository"].(map[string]interface{})["pullRequests"].(map[string]interface{})["nodes"].([]interface{})
+		nodes[0].(map[string]interface{})["createdAt"] = "not a date"
+		return page
+	}, nil)
+
+	prs, _, err := FetchExternalPRs(context.Background(), client, Options{Owner: "rancher", Repos: []string{"rancher"}})
+	if err != nil {
+		t.Fatalf("FetchExternalPRs() error = %v", err)
+	}
+	if len(prs) != 2 {
+		t.Fatalf("got %d PRs, want both despite the malformed date", len(prs))
+	}
+	if !prs[0].CreatedAt.IsZero() || prs[1].CreatedAt.IsZero() {
+		t.Errorf("CreatedAt = %v, %v, want the zero time for the malformed date only", prs[0].CreatedAt, prs[1].CreatedAt)
+	}
+}
+
+func TestFetchPullRequestsManyLabels(t *testing.T) {
+	labels := func(from, to int, hasNextPage bool) interface{} {
+		var nodes []interface{}
+		for i := from; i < to; i++ {
+			nodes = append(nodes, map[string]interface{}{"name": fmt.Sprintf("label%d", i)})
+		}
+		return map[string]interface{}{
+			"nodes":    nodes,
+			"pageInfo": map[string]interface{}{"endCursor": fmt.Sprintf("label-cursor%d", to), "hasNextPage": hasNextPage},
+		}
+	}
+
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if strings.Contains(req.Query, "pullRequest(number:") {
+			if req.Variables["prNumber"] != float64(1) || req.Variables["cursor"] != "label-cursor20" {
+				t.Errorf("unexpected label query variables %v", req.Variables)
+			}
+			return map[string]interface{}{"repository": map[string]interface{}{"pullRequest": map[string]interface{}{"labels": labels(20, 25, false)}}}
+		}
+		page := pullRequestsPage(1, 2, false).(map[string]interface{})
+		nodes := page["repository"].(map[string]interface{})["pullRequests"].(map[string]interface{})["nodes"].([]interface{})
+		nodes[0].(map[string]interface{})["labels"] = labels(0, 20, true)
+		nodes[1].(map[string]interface{})["labels"] = labels(0, 3, false)
+		return page
+	}, nil)
+
+	prs, err := FetchPullRequests(context.Background(), client, "rancher", "rancher", nil, 0)
+	if err != nil {
+		t.Fatalf("FetchPullRequests() error = %v", err)
+	}
+	if len(prs[0].Labels) != 25 || prs[0].Labels[24] != "label24" {
+		t.Errorf("PR #1 has labels %v, want label0..label24", prs[0].Labels)
+	}
+	if len(prs[1].Labels) != 3 {
+		t.Errorf("PR #2 has labels %v, want 3", prs[1].Labels)
+	}
+}
+
+func TestFetchRemainingLabelsRateLimit(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		return map[string]interface{}{
+			"repository": map[string]interface{}{"pullRequest": map[string]interface{}{"labels": map[string]interface{}{
+				"nodes":    []interface{}{map[string]interface{}{"name": "label20"}},
+				"pageInfo": map[string]interface{}{"hasNextPage": false},
+			}}},
+			"rateLimit": map[string]interface{}{"cost": 1, "remaining": 5, "resetAt": time.Now().Add(time.Hour).Format(time.RFC3339)},
+		}
+	}, nil)
+	client.SetRateLimit(10, RateLimitAbort)
+
+	if _, err := fetchRemainingLabels(context.Background(), client, "rancher", "rancher", 1, "label-cursor20"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("fetchRemainingLabels() with the rate limit running low error = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestMatchesPath(t *testing.T) {
+	tests := []struct {
+		file    string
+		pattern string
+		want    bool
+	}{
+		{file: "pkg/agent/main.go", pattern: "pkg/agent/**", want: true},
+		{file: "pkg/agent/plan/plan.go", pattern: "pkg/agent/**", want: true},
+		{file: "pkg/api/main.go", pattern: "pkg/agent/**", want: false},
+		{file: "pkg/agent/main.go", pattern: "pkg/agent", want: true},
+		{file: "docs/install.md", pattern: "docs/*.md", want: true},
+		{file: "docs/guide/install.md", pattern: "docs/*.md", want: false},
+		{file: "README.md", pattern: "pkg/", want: false},
+	}
+	for _, tt := range tests {
+		if got := MatchesPath(tt.file, tt.pattern); got != tt.want {
+			t.Errorf("MatchesPath(%q, %q) = %v, want %v", tt.file, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestFetchExternalPRsPathPrefixes(t *testing.T) {
+	filesQueried := 0
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if strings.Contains(req.Query, "files(") {
+			filesQueried++
+			var nodes []interface{}
+			if req.Variables["prNumber"] == float64(2) {
+				nodes = append(nodes, map[string]interface{}{"path": "pkg/agent/main.go"})
+			} else {
+				nodes = append(nodes, map[string]interface{}{"path": "docs/README.md"})
+			}
+			return map[string]interface{}{"repository": map[string]interface{}{"pullRequest": map[string]interface{}{"files": map[string]interface{}{"nodes": nodes}}}}
+		}
+		return pullRequestsPage(1, 3, false)
+	}, nil)
+
+	opts := Options{
+		Owner:        "rancher",
+		Repos:        []string{"rancher"},
+		Members:      map[string]bool{"user3": true},
+		PathPrefixes: []string{"pkg/agent/**"},
+	}
+	prs, _, err := FetchExternalPRs(context.Background(), client, opts)
+	if err != nil {
+		t.Fatalf("FetchExternalPRs() error = %v", err)
+	}
+	if len(prs) != 1 || prs[0].Number != 2 {
+		t.Errorf("got PRs %+v, want only #2", prs)
+	}
+	if filesQueried != 2 {
+		t.Errorf("queried files of %d PRs, want 2: the member's PR must not be queried", filesQueried)
+	}
+}
+
+func TestFetchExternalPRsPartialErrors(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		notFound := []map[string]interface{}{{"message": "Could not resolve to a Repository"}}
+		if req.Variables["repo"] == "private" {
+			return graphqlResponse{Data: map[string]interface{}{"repository": nil, "rateLimit": map[string]interface{}{"remaining": 5000}}, Errors: notFound}
+		}
+		// A field the token cannot read comes back as null with an error, the rest of the page is intact
+		page := pullRequestsPage(1, 2, false).(map[string]interface{})
+		nodes := page["repository"].(map[string]interface{})["pullRequests"].(map[string]interface{})["nodes"].([]interface{})
+		nodes[0].(map[string]interface{})["closingIssuesReferences"] = nil
+		return graphqlResponse{Data: page, Errors: []map[string]interface{}{{"message": "Resource not accessible by integration"}}}
+	}, nil)
+
+	prs, _, err := FetchExternalPRs(context.Background(), client, Options{Owner: "rancher", Repos: []string{"private", "rancher"}})
+	if err != nil {
+		t.Fatalf("FetchExternalPRs() error = %v", err)
+	}
+	if len(prs) != 2 || prs[0].Repo != "rancher" || len(prs[0].LinkedIssues) != 0 {
+		t.Errorf("got PRs %+v, want #1 and #2 of rancher", prs)
+	}
+
+	if _, _, err := FetchExternalPRs(context.Background(), client, Options{Owner: "rancher", Repos: []string{"private"}}); err == nil {
+		t.Error("FetchExternalPRs() of an inaccessible repository should fail")
+	}
+}
@@ -0,0 +1,64 @@
+package publicprs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/machinebox/graphql"
+)
+
+// UserID fetches the global ID of the user with the given login, as expected by RequestReviews.
+func UserID(ctx context.Context, client *Client, login string) (string, error) {
+	req := graphql.NewRequest(`
+		query($login: String!) {
+			user(login: $login) {
+				id
+			}
+		}
+	`)
+	req.Var("login", login)
+
+	var resp struct {
+		User *struct {
+			ID string
+		}
+	}
+	if err := client.run(ctx, req, &resp); err != nil {
+		return "", fmt.Errorf("error fetching user %s: %w", login, err)
+	}
+	if resp.User == nil {
+		return "", fmt.Errorf("user %s not found", login)
+	}
+	return resp.User.ID, nil
+}
+
+// RequestReviews requests a review of the PR with the given global ID from the users with the given
+// global IDs, keeping the reviews already requested.
+func RequestReviews(ctx context.Context, client *Client, prID string, userIDs []string) error {
+	req := graphql.NewRequest(`
+		mutation($prID: ID!, $userIDs: [ID!]) {
+			requestReviews(input: {pullRequestId: $prID, userIds: $userIDs, union: true}) {
+				pullRequest {
+					id
+				}
+			}
+		}
+	`)
+
+	req.Var("prID", prID)
+	req.Var("userIDs", userIDs)
+
+	var resp struct {
+		RequestReviews struct {
+			PullRequest struct {
+				ID string `json:"id"`
+			} `json:"pullRequest"`
+		} `json:"requestReviews"`
+	}
+
+	if err := client.run(ctx, req, &resp); err != nil {
+		return fmt.Errorf("error requesting reviews: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package publicprs
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUserID(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if req.Variables["login"] != "jdoe" {
+			return map[string]interface{}{"user": nil}
+		}
+		return map[string]interface{}{"user": map[string]interface{}{"id": "U_jdoe"}}
+	}, nil)
+
+	if id, err := UserID(context.Background(), client, "jdoe"); err != nil || id != "U_jdoe" {
+		t.Errorf("UserID() = %q, %v, want U_jdoe", id, err)
+	}
+	if _, err := UserID(context.Background(), client, "nobody"); err == nil {
+		t.Error("UserID() of a missing user succeeded, want an error")
+	}
+}
+
+func TestRequestReviews(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if !strings.Contains(req.Query, "union: true") {
+			t.Error("reviews must be requested without removing the existing requests")
+		}
+		if req.Variables["prID"] != "PR_12" || len(req.Variables["userIDs"].([]interface{})) != 1 {
+			t.Errorf("unexpected variables %v", req.Variables)
+		}
+		return map[string]interface{}{"requestReviews": map[string]interface{}{"pullRequest": map[string]interface{}{"id": "PR_12"}}}
+	}, nil)
+
+	if err := RequestReviews(context.Background(), client, "PR_12", []string{"U_jdoe"}); err != nil {
+		t.Errorf("RequestReviews() error = %v", err)
+	}
+}
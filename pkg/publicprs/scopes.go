@@ -0,0 +1,63 @@
+package publicprs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// impliedScopes lists, for each OAuth scope, the broader scopes that grant it as well.
+var impliedScopes = map[string][]string{
+	"read:org":     {"write:org", "admin:org"},
+	"write:org":    {"admin:org"},
+	"read:project": {"project"},
+	"public_repo":  {"repo"},
+}
+
+// TokenScopes returns the OAuth scopes of the client's token, as reported by GitHub in the
+// X-OAuth-Scopes header.  Only classic personal access tokens report scopes; known is false for
+// fine-grained tokens and GitHub App installation tokens, whose permissions cannot be inspected
+// this way.  The rate limit endpoint is used since it doesn't count against the rate limit.
+func TokenScopes(ctx context.Context, client *Client) (scopes []string, known bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", client.restURL+"/rate_limit", nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating request: %w", err)
+	}
+	resp, err := client.rest.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("error checking token scopes: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := statusError(resp); err != nil {
+		return nil, false, fmt.Errorf("error checking token scopes: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("error checking token scopes: received non-OK response %d", resp.StatusCode)
+	}
+
+	header, known := resp.Header["X-Oauth-Scopes"]
+	if !known {
+		return nil, false, nil
+	}
+	for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, true, nil
+}
+
+// HasScope reports whether scopes grant scope, directly or through a broader scope.
+func HasScope(scopes []string, scope string) bool {
+	if slices.Contains(scopes, scope) {
+		return true
+	}
+	for _, broader := range impliedScopes[scope] {
+		if slices.Contains(scopes, broader) {
+			return true
+		}
+	}
+	return false
+}
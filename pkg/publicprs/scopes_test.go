@@ -0,0 +1,51 @@
+package publicprs
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestTokenScopes(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    []string
+		want      []string
+		wantKnown bool
+	}{
+		{name: "classic token", header: []string{"repo, read:org"}, want: []string{"repo", "read:org"}, wantKnown: true},
+		{name: "classic token without scopes", header: []string{""}, wantKnown: true},
+		{name: "fine-grained token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/rate_limit" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				if tt.header != nil {
+					w.Header()["X-Oauth-Scopes"] = tt.header
+				}
+				w.Write([]byte("{}"))
+			}))
+
+			scopes, known, err := TokenScopes(context.Background(), client)
+			if err != nil {
+				t.Fatalf("TokenScopes() error = %v", err)
+			}
+			if known != tt.wantKnown || !slices.Equal(scopes, tt.want) {
+				t.Errorf("TokenScopes() = %q, %v, want %q, %v", scopes, known, tt.want, tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	if !HasScope([]string{"repo", "admin:org"}, "read:org") {
+		t.Error("admin:org should grant read:org")
+	}
+	if HasScope([]string{"repo", "read:project"}, "project") {
+		t.Error("read:project should not grant project")
+	}
+}
@@ -0,0 +1,145 @@
+package publicprs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/machinebox/graphql"
+)
+
+// maxSearchResults is the number of results GitHub's search API returns at most for a query.
+const maxSearchResults = 1000
+
+// SearchPullRequests fetches the pull requests matching a GitHub search query, such as
+// "repo:rancher/rancher is:open -author:app/dependabot".  The is:pr qualifier is added when the
+// query doesn't restrict the results to PRs already.  Paging stops once limit PRs have been
+// fetched; a limit of zero fetches every PR, up to the 1000 results GitHub returns for a search.
+func SearchPullRequests(ctx context.Context, client *Client, query string, limit int) ([]PullRequest, error) {
+	return searchPullRequests(ctx, client, query, limit, false, nil)
+}
+
+// searchQuery returns query restricted to PRs.
+func searchQuery(query string) string {
+	for _, term := range strings.Fields(query) {
+		if term == "is:pr" || term == "type:pr" {
+			return query
+		}
+	}
+	return strings.TrimSpace(query + " is:pr")
+}
+
+// searchPullRequests implements SearchPullRequests like fetchPullRequests implements
+// FetchPullRequests.
+func searchPullRequests(ctx context.Context, client *Client, query string, limit int, includeBody bool, onPage func(fetched, total int)) ([]PullRequest, error) {
+	query = searchQuery(query)
+	cursor := ""
+	var pullRequests []PullRequest
+	first := client.perPage()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		req := graphql.NewRequest(`
+			query SearchPullRequests($query: String!, $cursor: String, $first: Int!, $includeBody: Boolean!) {
+				search(query: $query, type: ISSUE, first: $first, after: $cursor) {
+					issueCount
+					nodes {
+						... on PullRequest {
+							...PullRequestFields
+							repository {
+								name
+								owner {
+									login
+								}
+							}
+						}
+					}
+					pageInfo {
+						endCursor
+						hasNextPage
+					}
+				}
+				rateLimit {
+					cost
+					remaining
+					resetAt
+				}
+			}
+		` + pullRequestFragment)
+		req.Var("query", query)
+		req.Var("cursor", cursor)
+		req.Var("first", first)
+		req.Var("includeBody", includeBody)
+
+		var resp struct {
+			Search struct {
+				IssueCount int
+				Nodes      []struct {
+					pullRequestNode
+					// The repository is only selected for PRs, so it is null for issues
+					Repository *struct {
+						Name  string
+						Owner struct {
+							Login string
+						}
+					}
+				}
+				PageInfo struct {
+					EndCursor   string
+					HasNextPage bool
+				}
+			}
+			RateLimit rateLimit
+		}
+
+		if err := client.runPartial(ctx, req, &resp); err != nil {
+			if isQueryTimeout(ctx, err) && first > minTimeoutPageSize {
+				first = max(first/2, minTimeoutPageSize)
+				slog.Warn("GitHub timed out searching PRs, retrying with a smaller page", "query", query, "pageSize", first, "err", err)
+				continue
+			}
+			return nil, fmt.Errorf("error searching PRs: %w", err)
+		}
+		if cursor == "" && resp.Search.IssueCount > maxSearchResults && (limit == 0 || limit > maxSearchResults) {
+			slog.Warn("The search matches more PRs than GitHub returns, narrow the query to get all of them", "query", query, "matches", resp.Search.IssueCount, "returned", maxSearchResults)
+		}
+
+		slog.Debug("Fetched PR search page", "query", query, "count", len(resp.Search.Nodes))
+
+		for _, node := range resp.Search.Nodes {
+			if node.Repository == nil {
+				continue
+			}
+			pr, err := node.toPullRequest(ctx, client, node.Repository.Owner.Login, node.Repository.Name)
+			if err != nil {
+				return nil, err
+			}
+			pullRequests = append(pullRequests, pr)
+		}
+
+		if err := client.checkRateLimit(ctx, "GraphQL", resp.RateLimit.Remaining, resp.RateLimit.ResetAt); err != nil {
+			return nil, err
+		}
+
+		if limit > 0 && len(pullRequests) >= limit {
+			pullRequests = pullRequests[:limit]
+		}
+		if onPage != nil {
+			onPage(len(pullRequests), min(resp.Search.IssueCount, maxSearchResults))
+		}
+		if limit > 0 && len(pullRequests) >= limit {
+			return pullRequests, nil
+		}
+
+		if !resp.Search.PageInfo.HasNextPage {
+			break
+		}
+		cursor = resp.Search.PageInfo.EndCursor
+	}
+
+	return pullRequests, nil
+}
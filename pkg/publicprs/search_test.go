@@ -0,0 +1,63 @@
+package publicprs
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSearchQuery(t *testing.T) {
+	tests := map[string]string{
+		"org:rancher is:open":          "org:rancher is:open is:pr",
+		"org:rancher is:pr is:open":    "org:rancher is:pr is:open",
+		"type:pr -author:app/renovate": "type:pr -author:app/renovate",
+	}
+	for query, want := range tests {
+		if got := searchQuery(query); got != want {
+			t.Errorf("searchQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestFetchExternalPRsSearch(t *testing.T) {
+	client := newTestClient(t, func(req graphqlRequest) interface{} {
+		if !strings.Contains(req.Query, "search(") || req.Variables["query"] != "org:rancher is:open is:pr" {
+			t.Errorf("unexpected query %v", req.Variables)
+		}
+		return map[string]interface{}{
+			"search": map[string]interface{}{
+				"issueCount": 3,
+				"nodes": []interface{}{
+					map[string]interface{}{
+						"id": "PR_1", "number": 1, "createdAt": "2024-01-01T00:00:00Z",
+						"author":     map[string]interface{}{"__typename": "User", "login": "jdoe"},
+						"repository": map[string]interface{}{"name": "dashboard", "owner": map[string]interface{}{"login": "rancher"}},
+					},
+					// Issues matched by the query come back empty, as only fields of PRs are selected
+					map[string]interface{}{},
+					map[string]interface{}{
+						"id": "PR_2", "number": 2, "createdAt": "2024-01-02T00:00:00Z",
+						"author":     map[string]interface{}{"__typename": "User", "login": "member"},
+						"repository": map[string]interface{}{"name": "rancher", "owner": map[string]interface{}{"login": "rancher"}},
+					},
+				},
+				"pageInfo": map[string]interface{}{"hasNextPage": false},
+			},
+		}
+	}, nil)
+
+	opts, err := NewOptions("rancher", nil, WithSearchQuery("org:rancher is:open"), WithMembers(map[string]bool{"member": true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, stats, err := FetchExternalPRs(context.Background(), client, opts)
+	if err != nil {
+		t.Fatalf("FetchExternalPRs() error = %v", err)
+	}
+	if len(got) != 1 || got[0].NameWithOwner() != "rancher/dashboard" || got[0].Number != 1 || got[0].Author != "jdoe" {
+		t.Errorf("FetchExternalPRs() = %+v, want PR 1 of rancher/dashboard", got)
+	}
+	if stats.Scanned != 2 || stats.External != 1 {
+		t.Errorf("stats = %+v, want 2 scanned and 1 external", stats)
+	}
+}
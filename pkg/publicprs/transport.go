@@ -0,0 +1,153 @@
+package publicprs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetryDelay caps how long a single retry waits, regardless of what GitHub asks for.
+const maxRetryDelay = 5 * time.Minute
+
+// retryTransport retries requests that GitHub rejected because of a primary or
+// secondary rate limit, waiting for Retry-After (or the rate limit reset) between attempts.
+// Idempotent requests that fail with a 502, 503 or 504 are retried too, with exponential
+// backoff and jitter starting at backoff.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	backoff    time.Duration
+}
+
+// NewRetryTransport wraps base so that rate limited requests, and idempotent requests failing with
+// a transient server error, are retried up to maxRetries times.  GraphQL mutations are never retried
+// after a server error, since GitHub may have applied them before failing.
+// A nil base uses http.DefaultTransport.
+func NewRetryTransport(base http.RoundTripper, maxRetries int) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, maxRetries: maxRetries, backoff: time.Second}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		var delay time.Duration
+		switch {
+		case isRateLimited(resp):
+			delay = retryDelay(resp, attempt)
+			slog.Warn("Rate limited by GitHub, retrying", "status", resp.StatusCode, "delay", delay, "attempt", attempt+1, "maxRetries", t.maxRetries)
+		case isServerError(resp) && isIdempotent(req):
+			delay = backoffDelay(t.backoff, attempt)
+			slog.Warn("GitHub server error, retrying", "status", resp.StatusCode, "delay", delay, "attempt", attempt+1, "maxRetries", t.maxRetries)
+		default:
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRateLimited reports whether resp is a rate limit rejection rather than a genuine
+// permission error.  GitHub uses 403 for both, so the headers and body are inspected.
+func isRateLimited(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return true
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return false
+		}
+		msg := strings.ToLower(string(body))
+		return strings.Contains(msg, "rate limit") || strings.Contains(msg, "abuse")
+	}
+	return false
+}
+
+// isServerError reports whether resp failed with a transient gateway or availability error.
+func isServerError(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isIdempotent reports whether req can safely be sent again after an ambiguous failure.
+// GraphQL requests are POSTs, so their body is inspected and only queries qualify; a mutation
+// such as addProjectV2ItemById may already have been applied.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		if req.GetBody == nil {
+			return false
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return false
+		}
+		defer body.Close()
+		var payload struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(body).Decode(&payload); err != nil || payload.Query == "" {
+			return false
+		}
+		return !strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation")
+	}
+	return false
+}
+
+// backoffDelay returns the exponential backoff for the given attempt, randomized between half and
+// all of it so that concurrent clients do not retry in lockstep.
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	delay := min(base<<attempt, maxRetryDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryDelay works out how long to wait before retrying a rate limited request.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	delay := time.Second << attempt
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			delay = time.Until(time.Unix(reset, 0))
+		}
+	}
+	return min(max(delay, time.Second), maxRetryDelay)
+}
@@ -0,0 +1,77 @@
+package publicprs
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status and then answers 200.
+func flakyServer(t *testing.T, failures, status int) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRetryTransportServerErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		status       int
+		failures     int
+		wantStatus   int
+		wantRequests int
+	}{
+		{name: "query retried on 502", body: `{"query":"query { viewer { login } }"}`, status: http.StatusBadGateway, failures: 2, wantStatus: http.StatusOK, wantRequests: 3},
+		{name: "query retried on 503", body: `{"query":"\n\t\tquery($owner: String!) { viewer { login } }"}`, status: http.StatusServiceUnavailable, failures: 1, wantStatus: http.StatusOK, wantRequests: 2},
+		{name: "query gives up after max retries", body: `{"query":"query { viewer { login } }"}`, status: http.StatusGatewayTimeout, failures: 10, wantStatus: http.StatusGatewayTimeout, wantRequests: 4},
+		{name: "mutation not retried", body: `{"query":"\n\t\tmutation($projectID: ID!) { addProjectV2ItemById }"}`, status: http.StatusBadGateway, failures: 1, wantStatus: http.StatusBadGateway, wantRequests: 1},
+		{name: "internal server error not retried", body: `{"query":"query { viewer { login } }"}`, status: http.StatusInternalServerError, failures: 1, wantStatus: http.StatusInternalServerError, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := flakyServer(t, tt.failures, tt.status)
+			client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, maxRetries: 3, backoff: time.Millisecond}}
+
+			resp, err := client.Post(server.URL, "application/json", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("Post() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if *requests != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", *requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt := 0; attempt < 5; attempt++ {
+		full := time.Second << attempt
+		for i := 0; i < 20; i++ {
+			delay := backoffDelay(time.Second, attempt)
+			if delay < full/2 || delay > full {
+				t.Fatalf("backoffDelay(1s, %d) = %v, want between %v and %v", attempt, delay, full/2, full)
+			}
+		}
+	}
+	if delay := backoffDelay(time.Second, 20); delay > maxRetryDelay {
+		t.Errorf("backoffDelay(1s, 20) = %v, want at most %v", delay, maxRetryDelay)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"publicprs/pkg/publicprs"
+)
+
+// preflight prints the outcome of each -preflight check as a checklist and counts the failures.
+type preflight struct {
+	w      io.Writer
+	failed int
+}
+
+// ok records a passed check, described by the format and args.
+func (p *preflight) ok(format string, args ...any) {
+	p.print("ok", format, args...)
+}
+
+// warn records a check that found a problem the run can live with.
+func (p *preflight) warn(format string, args ...any) {
+	p.print("warn", format, args...)
+}
+
+// fail records a failed check.
+func (p *preflight) fail(format string, args ...any) {
+	p.failed++
+	p.print("FAIL", format, args...)
+}
+
+func (p *preflight) print(status, format string, args ...any) {
+	fmt.Fprintf(p.w, "[%s] %s\n", status, fmt.Sprintf(format, args...))
+}
+
+// runPreflight checks the configuration against GitHub without scanning any PRs, for -preflight:
+// that the token works and has the scopes the run needs, that every repository, organization and
+// team can be read and, with -addtoproject or -prune, that every project resolves and the token can
+// update it.  Each check is printed to w, and an error is returned when any of them failed.
+func runPreflight(ctx context.Context, client *publicprs.Client, cfg config, projectNumbers []int, w io.Writer) error {
+	p := &preflight{w: w}
+
+	login, remaining, err := publicprs.Viewer(ctx, client)
+	switch {
+	case err != nil:
+		p.fail("token: %v", err)
+		// Nothing else can work without a working token
+		return errors.New("preflight failed: the token does not work")
+	case login == "":
+		p.ok("token: authenticated as a GitHub App installation, %d GraphQL points left", remaining)
+	default:
+		p.ok("token: authenticated as %s, %d GraphQL points left", login, remaining)
+	}
+
+	scopes, known, err := publicprs.TokenScopes(ctx, client)
+	switch {
+	case err != nil:
+		p.fail("token scopes: %v", err)
+	case !known:
+		p.ok("token scopes: not inspectable for fine-grained or App tokens, skipped")
+	default:
+		if missing := missingScopes(cfg, scopes); len(missing) > 0 && cfg.strictScopes {
+			p.fail("token scopes: missing %s", strings.Join(missing, ", "))
+		} else if len(missing) > 0 {
+			p.warn("token scopes: missing %s", strings.Join(missing, ", "))
+		} else {
+			p.ok("token scopes: %s", strings.Join(scopes, ", "))
+		}
+	}
+
+	for _, repo := range cfg.repos {
+		owner, name := publicprs.SplitRepo(cfg.owner, repo)
+		if err := publicprs.CheckRepository(ctx, client, owner, name); err != nil {
+			p.fail("repository %s/%s: %v", owner, name, err)
+		} else {
+			p.ok("repository %s/%s", owner, name)
+		}
+	}
+
+	for _, source := range slices.Concat(cfg.orgs, cfg.teams) {
+		if err := publicprs.CheckMembershipSource(ctx, client, source); err != nil {
+			p.fail("%s: %v", describeSource(source), err)
+		} else {
+			p.ok("%s", describeSource(source))
+		}
+	}
+
+	for _, number := range projectNumbers {
+		id, err := publicprs.GetProjectV2ID(ctx, client, cfg.projectOwner, number)
+		if err != nil {
+			p.fail("project %d of %s: %v", number, cfg.projectOwner, err)
+			continue
+		}
+		title, canUpdate, err := publicprs.ProjectV2Access(ctx, client, id)
+		switch {
+		case err != nil:
+			p.fail("project %d of %s: %v", number, cfg.projectOwner, err)
+		case !canUpdate && !cfg.dryRun:
+			p.fail("project %d of %s (%s): the token cannot update it", number, cfg.projectOwner, title)
+		default:
+			p.ok("project %d of %s (%s)", number, cfg.projectOwner, title)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.failed > 0 {
+		return fmt.Errorf("preflight failed: %d checks failed", p.failed)
+	}
+	return nil
+}
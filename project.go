@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"publicprs/pkg/publicprs"
+)
+
+// project is a GitHub project that reported PRs are added to or pruned from.
+type project struct {
+	number int
+	id     string
+	// items are the project's PR items as loaded before any were added, and inProject the content
+	// IDs of all its PRs and issues, which grows as they are added.  mu guards inProject while PRs
+	// are added concurrently.
+	items     []publicprs.ProjectItem
+	mu        sync.Mutex
+	inProject map[string]bool
+	// statusFieldID and statusOptionID are the -setstatus field and option of the project
+	statusFieldID  string
+	statusOptionID string
+}
+
+// parseProjectNumbers parses the -project list of project numbers.
+func parseProjectNumbers(values []string) ([]int, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("invalid -project %q: expected at least one project number", strings.Join(values, ","))
+	}
+	numbers := make([]int, 0, len(values))
+	for _, value := range values {
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || number <= 0 {
+			return nil, fmt.Errorf("invalid -project %q: expected a comma-separated list of positive project numbers", strings.Join(values, ","))
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
+
+// resolveProjects looks up the global IDs of the numbered projects of owner.  Projects that cannot be
+// resolved are skipped with a warning, so one missing board doesn't stop the others from being
+// updated; it only fails when none of them resolve.  IDs are cached on disk for projectCacheTTL, as
+// they never change, and looked up again with refresh.
+func resolveProjects(ctx context.Context, client *publicprs.Client, owner string, numbers []int, refresh bool) ([]*project, error) {
+	cache := loadCachedProjectIDs()
+	updated := false
+	var projects []*project
+	for _, number := range numbers {
+		key := projectCacheKey(client.Host(), owner, number)
+		if cached, ok := cache[key]; ok && !refresh && time.Since(cached.FetchedAt) < projectCacheTTL {
+			slog.Debug("Using cached project ID", "project", number, "id", cached.ID)
+			projects = append(projects, &project{number: number, id: cached.ID})
+			continue
+		}
+
+		id, err := publicprs.GetProjectV2ID(ctx, client, owner, number)
+		if err == nil && id == "" {
+			err = fmt.Errorf("project #%d of %s has no ID", number, owner)
+		}
+		if err != nil {
+			slog.Warn("Skipping project", "project", number, "err", err)
+			continue
+		}
+		cache[key] = cachedProjectID{ID: id, FetchedAt: time.Now()}
+		updated = true
+		projects = append(projects, &project{number: number, id: id})
+	}
+	if updated {
+		if err := saveCachedProjectIDs(cache); err != nil {
+			slog.Warn("Unable to cache project IDs", "err", err)
+		}
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("%w: none of the projects %v of %s could be resolved; check -project, -projectowner and that the token can read projects", publicprs.ErrProjectNotFound, numbers, owner)
+	}
+	return projects, nil
+}
+
+// contains reports whether the PR or issue with the given global ID is in the project.
+func (p *project) contains(contentID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inProject[contentID]
+}
+
+// claim records contentID as in the project before it is added, so concurrent additions add and
+// report each PR once.  It returns false when the content was already in the project or claimed.
+func (p *project) claim(contentID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inProject[contentID] {
+		return false
+	}
+	p.inProject[contentID] = true
+	return true
+}
+
+// release forgets a claim whose addition failed.
+func (p *project) release(contentID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.inProject, contentID)
+}
+
+// projectList describes the -project numbers for the summary, e.g. "project 79" or
+// "projects 79, 80".
+func projectList(values []string) string {
+	if len(values) == 1 {
+		return "project " + values[0]
+	}
+	return "projects " + strings.Join(values, ", ")
+}
+
+// addToProject adds pr to p, or with -dryrun reports whether it would be added, describing the
+// change on w.  With a confirmer, the PR is only added once the user accepts it.  It reports
+// whether the PR was, or would be, added.  Errors are logged rather than returned so the remaining
+// PRs and projects are still processed.  It is safe to call concurrently for different PRs, as long
+// as w is safe for concurrent writes and confirm is nil.
+func addToProject(ctx context.Context, client *publicprs.Client, cfg config, w io.Writer, p *project, pr publicprs.PullRequest, confirm *confirmer) bool {
+	if confirm != nil && confirm.quit {
+		return false
+	}
+	prID, err := publicprs.ResolvePullRequestID(ctx, client, pr)
+	if err != nil {
+		slog.Error("Error adding PR to project", "pr", pr.Number, "project", p.number, "err", err)
+		return false
+	}
+	var ask func() bool
+	if confirm != nil {
+		ask = func() bool { return confirm.confirm(pr, p.number) }
+	}
+	return addContent(ctx, client, cfg, w, p, prID, fmt.Sprintf("PR #%d", pr.Number), ask)
+}
+
+// addIssueToProject adds issue to p like addToProject adds a PR.
+func addIssueToProject(ctx context.Context, client *publicprs.Client, cfg config, w io.Writer, p *project, issue publicprs.Issue, confirm *confirmer) bool {
+	if confirm != nil && confirm.quit {
+		return false
+	}
+	var ask func() bool
+	if confirm != nil {
+		ask = func() bool { return confirm.confirmIssue(issue, p.number) }
+	}
+	return addContent(ctx, client, cfg, w, p, issue.ID, fmt.Sprintf("Issue #%d", issue.Number), ask)
+}
+
+// addContent adds the PR or issue with the given global ID to p for addToProject and
+// addIssueToProject.  name describes the content in messages, such as "PR #12", and ask, when set,
+// confirms the addition.
+func addContent(ctx context.Context, client *publicprs.Client, cfg config, w io.Writer, p *project, contentID, name string, ask func() bool) bool {
+	if p.contains(contentID) {
+		fmt.Fprintf(w, "%s already in project %v\n", name, p.number)
+		return false
+	}
+	if cfg.dryRun {
+		fmt.Fprintf(w, "Would add %s to project %v (dry run)\n", name, p.number)
+		return true
+	}
+	if ask != nil && !ask() {
+		return false
+	}
+
+	// Another addition may have claimed the content since it was checked; GitHub would return the
+	// same item, but it must only be reported once
+	if !p.claim(contentID) {
+		fmt.Fprintf(w, "%s already in project %v\n", name, p.number)
+		return false
+	}
+	itemID, err := publicprs.AddProjectItem(ctx, client, p.id, contentID)
+	switch {
+	case errors.Is(err, publicprs.ErrAlreadyInProject):
+		// Added since the project's items were loaded, e.g. by someone else
+		fmt.Fprintf(w, "%s already in project %v\n", name, p.number)
+		return false
+	case errors.Is(err, publicprs.ErrProjectArchived):
+		p.release(contentID)
+		slog.Warn("Not adding to project, it is archived", "content", name, "project", p.number)
+		return false
+	case errors.Is(err, publicprs.ErrContentNotFound):
+		p.release(contentID)
+		slog.Warn("Not adding to project, the project or the content no longer exists or is not visible to the token", "content", name, "project", p.number, "err", err)
+		return false
+	case err != nil:
+		p.release(contentID)
+		slog.Error("Error adding to project", "content", name, "project", p.number, "err", err)
+		return false
+	}
+	fmt.Fprintf(w, "%s added to project %v\n", name, p.number)
+	if p.statusFieldID != "" {
+		if err := publicprs.SetSingleSelectValue(ctx, client, p.id, itemID, p.statusFieldID, p.statusOptionID); err != nil {
+			slog.Error("Error setting project status", "content", name, "project", p.number, "err", err)
+		} else {
+			fmt.Fprintf(w, "%s status set to %s in project %v\n", name, cfg.setStatus, p.number)
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseProjectNumbers(t *testing.T) {
+	tests := []struct {
+		values  []string
+		want    []int
+		wantErr bool
+	}{
+		{values: []string{"79"}, want: []int{79}},
+		{values: []string{"79", " 80"}, want: []int{79, 80}},
+		{values: nil, wantErr: true},
+		{values: []string{"79", "board"}, wantErr: true},
+		{values: []string{"0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseProjectNumbers(tt.values)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseProjectNumbers(%q) error = %v, wantErr %v", tt.values, err, tt.wantErr)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseProjectNumbers(%q) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestProjectClaim(t *testing.T) {
+	p := &project{inProject: map[string]bool{"PR_existing": true}}
+	if p.claim("PR_existing") {
+		t.Error("claim of a PR already in the project succeeded")
+	}
+
+	// Only one of several concurrent additions of the same PR may claim it
+	var wg sync.WaitGroup
+	var claimed atomic.Int32
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if p.claim("PR_new") {
+				claimed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if claimed.Load() != 1 {
+		t.Errorf("PR claimed %d times, want 1", claimed.Load())
+	}
+
+	p.release("PR_new")
+	if p.contains("PR_new") {
+		t.Error("released PR still in the project")
+	}
+}
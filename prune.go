@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"publicprs/pkg/publicprs"
+)
+
+// pruneProject removes the PRs whose authors are now members from p, describing each removal on
+// w, and returns how many were removed, or would be removed in a dry run.  Only the items loaded
+// before any PRs were added are considered and, with -prunestatus, only those whose field still has
+// the given option, so items someone has already triaged are kept.
+func pruneProject(ctx context.Context, client *publicprs.Client, cfg config, w io.Writer, p *project, members map[string]bool) (int, error) {
+	fieldName, optionName, filtered := strings.Cut(cfg.pruneStatus, "=")
+	removed := 0
+	for _, item := range p.items {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if !members[item.Author] {
+			continue
+		}
+		if filtered {
+			if value := item.FieldValue(fieldName); !strings.EqualFold(value, optionName) {
+				slog.Debug("Keeping PR of a member, its status does not match -prunestatus", "pr", item.Number, "project", p.number, "field", fieldName, "value", value)
+				continue
+			}
+		}
+		if cfg.dryRun {
+			fmt.Fprintf(w, "Would remove PR #%d (%s/%s) by %s from project %v (dry run)\n", item.Number, item.Owner, item.Repo, item.Author, p.number)
+			removed++
+			continue
+		}
+		if err := publicprs.DeleteProjectItem(ctx, client, p.id, item.ID); err != nil {
+			slog.Error("Error removing PR from project", "pr", item.Number, "project", p.number, "err", err)
+			continue
+		}
+		fmt.Fprintf(w, "Removed PR #%d (%s/%s) by %s from project %v\n", item.Number, item.Owner, item.Repo, item.Author, p.number)
+		removed++
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"publicprs/pkg/publicprs"
+)
+
+// authorGroup holds the external PRs opened by a single author.
+type authorGroup struct {
+	Author       string
+	PullRequests []publicprs.PullRequest
+}
+
+// groupByAuthor groups PRs by author, ordered by PR count with the most active authors first.
+// PRs keep their relative order within a group.
+func groupByAuthor(pullRequests []publicprs.PullRequest) []authorGroup {
+	index := make(map[string]int)
+	var groups []authorGroup
+	for _, pr := range pullRequests {
+		i, ok := index[pr.Author]
+		if !ok {
+			i = len(groups)
+			index[pr.Author] = i
+			groups = append(groups, authorGroup{Author: pr.Author})
+		}
+		groups[i].PullRequests = append(groups[i].PullRequests, pr)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].PullRequests) != len(groups[j].PullRequests) {
+			return len(groups[i].PullRequests) > len(groups[j].PullRequests)
+		}
+		return groups[i].Author < groups[j].Author
+	})
+	return groups
+}
+
+// anonymizeAuthors returns a copy of pullRequests with every author login, and the head repository
+// owner of PRs from the author's fork, replaced by anonymousLogin.
+func anonymizeAuthors(pullRequests []publicprs.PullRequest) []publicprs.PullRequest {
+	anonymized := make([]publicprs.PullRequest, 0, len(pullRequests))
+	for _, pr := range pullRequests {
+		if pr.HeadOwner == pr.Author {
+			pr.HeadOwner = anonymousLogin(pr.Author)
+		}
+		pr.Author = anonymousLogin(pr.Author)
+		anonymized = append(anonymized, pr)
+	}
+	return anonymized
+}
+
+// markStale returns a copy of pullRequests with Stale set on the PRs created before cutoff, for
+// -warnage.  PRs whose creation time could not be parsed are not flagged.
+func markStale(pullRequests []publicprs.PullRequest, cutoff time.Time) []publicprs.PullRequest {
+	marked := make([]publicprs.PullRequest, 0, len(pullRequests))
+	for _, pr := range pullRequests {
+		pr.Stale = !pr.CreatedAt.IsZero() && pr.CreatedAt.Before(cutoff)
+		marked = append(marked, pr)
+	}
+	return marked
+}
+
+// staleMarker returns the marker prepended to the text listing of a stale PR.
+func staleMarker(pr publicprs.PullRequest) string {
+	if pr.Stale {
+		return "[STALE] "
+	}
+	return ""
+}
+
+// anonymizeIssueAuthors returns a copy of issues with every author login replaced by anonymousLogin.
+func anonymizeIssueAuthors(issues []publicprs.Issue) []publicprs.Issue {
+	anonymized := make([]publicprs.Issue, 0, len(issues))
+	for _, issue := range issues {
+		issue.Author = anonymousLogin(issue.Author)
+		anonymized = append(anonymized, issue)
+	}
+	return anonymized
+}
+
+// anonymousLogin replaces login with the first 8 hex digits of its SHA-256 hash, so the same author
+// gets the same name in every report.  Deleted accounts are left as they are.
+func anonymousLogin(login string) string {
+	if login == publicprs.DeletedUser {
+		return login
+	}
+	sum := sha256.Sum256([]byte(login))
+	return hex.EncodeToString(sum[:4])
+}
+
+// printAuthorGroups prints one line per author listing their PR numbers.  PR numbers are
+// prefixed with the repository name when more than one repository was scanned.
+func printAuthorGroups(w io.Writer, groups []authorGroup, multiRepo bool) {
+	for _, group := range groups {
+		numbers := make([]string, 0, len(group.PullRequests))
+		for _, pr := range group.PullRequests {
+			if multiRepo {
+				numbers = append(numbers, fmt.Sprintf("%s#%d", pr.Repo, pr.Number))
+			} else {
+				numbers = append(numbers, fmt.Sprintf("#%d", pr.Number))
+			}
+		}
+		noun := "PRs"
+		if len(numbers) == 1 {
+			noun = "PR"
+		}
+		fmt.Fprintf(w, "\n%s (%d %s): %s", group.Author, len(numbers), noun, strings.Join(numbers, ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+// printOrgCounts prints how many of pullRequests were opened by public members of each partner
+// organization, as annotated by annotateAuthorOrgs.
+func printOrgCounts(w io.Writer, partners []string, pullRequests []publicprs.PullRequest) {
+	fmt.Fprintf(w, "\nPRs by members of partner organizations:")
+	for _, partner := range partners {
+		count := 0
+		for _, pr := range pullRequests {
+			if slices.Contains(pr.AuthorOrgs, partner) {
+				count++
+			}
+		}
+		fmt.Fprintf(w, "\n%s: %d", partner, count)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeMarkdownReport writes pullRequests as a GitHub Markdown table, for pasting into an issue.
+// PR numbers link to the PRs and are prefixed with the repository name when more than one
+// repository was scanned.
+func writeMarkdownReport(w io.Writer, pullRequests []publicprs.PullRequest, multiRepo bool) {
+	fmt.Fprintln(w, "| PR | Author | Title | Created |")
+	fmt.Fprintln(w, "| --- | --- | --- | --- |")
+	for _, pr := range pullRequests {
+		number := fmt.Sprintf("#%d", pr.Number)
+		if multiRepo {
+			number = pr.Repo + number
+		}
+		fmt.Fprintf(w, "| [%s](%s) | @%s | %s | %s |\n", number, pr.URL, pr.Author, markdownCell(pr.Title), pr.CreatedAt.Format(time.DateOnly))
+	}
+}
+
+// writeMarkdownIssues writes issues as a GitHub Markdown table like writeMarkdownReport.
+func writeMarkdownIssues(w io.Writer, issues []publicprs.Issue, multiRepo bool) {
+	fmt.Fprintln(w, "| Issue | Author | Title | Created |")
+	fmt.Fprintln(w, "| --- | --- | --- | --- |")
+	for _, issue := range issues {
+		number := fmt.Sprintf("#%d", issue.Number)
+		if multiRepo {
+			number = issue.Repo + number
+		}
+		fmt.Fprintf(w, "| [%s](%s) | @%s | %s | %s |\n", number, issue.URL, issue.Author, markdownCell(issue.Title), issue.CreatedAt.Format(time.DateOnly))
+	}
+}
+
+// markdownCell escapes text for a Markdown table cell, where a pipe would end the cell.
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// headRef describes where the changes of pr come from, as owner:branch like GitHub shows it, or
+// "deleted fork" when the head repository no longer exists.
+func headRef(pr publicprs.PullRequest) string {
+	if pr.HeadOwner == "" {
+		return "deleted fork"
+	}
+	return pr.HeadOwner + ":" + pr.HeadBranch
+}
+
+// mergeableStatus describes whether pr can be merged without conflicts, such as "yes" or
+// "conflicting".
+func mergeableStatus(pr publicprs.PullRequest) string {
+	switch pr.Mergeable {
+	case "MERGEABLE":
+		return "yes"
+	case "CONFLICTING":
+		return "conflicting"
+	}
+	// GitHub computes mergeability in the background and reports UNKNOWN until it is done
+	return "unknown (still being computed by GitHub)"
+}
+
+// checksStatus describes the combined state of the checks of the last commit of pr, such as
+// "SUCCESS", or returns "none" when the commit has no checks.
+func checksStatus(pr publicprs.PullRequest) string {
+	if pr.ChecksState == "" {
+		return "none"
+	}
+	return pr.ChecksState
+}
+
+// milestoneName describes the milestone of pr for the text report, or returns "none".
+func milestoneName(pr publicprs.PullRequest) string {
+	if pr.Milestone == "" {
+		return "none"
+	}
+	return pr.Milestone
+}
+
+// maxBodySummary is the number of characters of a PR description shown in the text report.
+const maxBodySummary = 200
+
+// bodySummary shortens the description of a PR to a single line of at most maxBodySummary
+// characters for the text report, or returns "none".
+func bodySummary(body string) string {
+	summary := []rune(strings.Join(strings.Fields(body), " "))
+	switch {
+	case len(summary) == 0:
+		return "none"
+	case len(summary) > maxBodySummary:
+		return string(summary[:maxBodySummary]) + "..."
+	}
+	return string(summary)
+}
+
+// reviewStatus describes the reviews of pr, such as "2, APPROVED" or "none".
+func reviewStatus(pr publicprs.PullRequest) string {
+	status := "none"
+	if pr.Reviews > 0 {
+		status = fmt.Sprint(pr.Reviews)
+	}
+	if pr.ReviewDecision != "" {
+		status += ", " + pr.ReviewDecision
+	}
+	return status
+}
+
+// loginList joins logins for the text report, or returns "none" when there are none.
+func loginList(logins []string) string {
+	if len(logins) == 0 {
+		return "none"
+	}
+	return strings.Join(logins, ", ")
+}
+
+// issueList describes the issues a PR closes for the text report, or returns "none".
+func issueList(issues []publicprs.LinkedIssue) string {
+	if len(issues) == 0 {
+		return "none"
+	}
+	descriptions := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		descriptions = append(descriptions, fmt.Sprintf("#%d %s", issue.Number, issue.Title))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// reportSummary counts what a run found and changed.
+type reportSummary struct {
+	Scanned  int  `json:"scanned"`
+	External int  `json:"external"`
+	Issues   int  `json:"externalIssues"`
+	Authors  int  `json:"authors"`
+	Bots     int  `json:"botsSkipped"`
+	Added    int  `json:"added"`
+	Removed  int  `json:"removed"`
+	New      int  `json:"new"`
+	DryRun   bool `json:"dryRun"`
+}
+
+// printSummary prints the closing line of the text report.
+func printSummary(w io.Writer, cfg config, s reportSummary) {
+	fmt.Fprintf(w, "%d PRs scanned, %d external PRs from %d authors, %d bot PRs skipped", s.Scanned, s.External, s.Authors, s.Bots)
+	if cfg.includeIssues {
+		fmt.Fprintf(w, ", %d external issues", s.Issues)
+	}
+	if cfg.stateFile != "" {
+		fmt.Fprintf(w, ", %d new since the last run", s.New)
+	}
+	switch {
+	case cfg.addToProject && s.DryRun:
+		fmt.Fprintf(w, ", %d would be added to %s", s.Added, projectList(cfg.projects))
+	case cfg.addToProject:
+		fmt.Fprintf(w, ", %d added to %s", s.Added, projectList(cfg.projects))
+	}
+	switch {
+	case cfg.prune && s.DryRun:
+		fmt.Fprintf(w, ", %d would be removed from %s", s.Removed, projectList(cfg.projects))
+	case cfg.prune:
+		fmt.Fprintf(w, ", %d removed from %s", s.Removed, projectList(cfg.projects))
+	}
+	fmt.Fprintln(w)
+}
+
+// reportSchemaVersion is the version of the JSON report.  It must be bumped whenever a field of
+// jsonReport, or of the types it contains, is renamed, removed or changes meaning, so consumers can
+// detect reports they do not understand.
+const reportSchemaVersion = 1
+
+// jsonReport is the document written by -format json.
+type jsonReport struct {
+	SchemaVersion int                     `json:"schemaVersion"`
+	PullRequests  []publicprs.PullRequest `json:"pullRequests"`
+	Issues        []publicprs.Issue       `json:"issues,omitempty"`
+	Authors       []authorSummary         `json:"authors,omitempty"`
+	Summary       reportSummary           `json:"summary"`
+}
+
+// authorSummary is an authorGroup in the JSON report.
+type authorSummary struct {
+	Author  string `json:"author"`
+	Count   int    `json:"count"`
+	Numbers []int  `json:"numbers"`
+}
+
+// authorSummaries converts groups for the JSON report.
+func authorSummaries(groups []authorGroup) []authorSummary {
+	summaries := make([]authorSummary, 0, len(groups))
+	for _, group := range groups {
+		summary := authorSummary{Author: group.Author, Count: len(group.PullRequests)}
+		for _, pr := range group.PullRequests {
+			summary.Numbers = append(summary.Numbers, pr.Number)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// writeJSONReport writes report as indented JSON, stamped with the current schema version.  PRs
+// are always written as a list, even when there are none.
+func writeJSONReport(w io.Writer, report jsonReport) {
+	report.SchemaVersion = reportSchemaVersion
+	if report.PullRequests == nil {
+		report.PullRequests = []publicprs.PullRequest{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+}
+
+// reportWriter is the destination of the report, stdout or a file.  It remembers the first write
+// error so the report can be written with fmt.Fprintf and checked once by Close.  Writes are
+// serialized, so concurrent project additions can describe their changes on it.
+type reportWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	file *os.File
+	err  error
+}
+
+// openReport returns a reportWriter for path, truncating the file, or for stdout when path is empty.
+func openReport(path string) (*reportWriter, error) {
+	if path == "" {
+		return &reportWriter{w: os.Stdout}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating report file: %w", err)
+	}
+	return &reportWriter{w: f, file: f}, nil
+}
+
+func (r *reportWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.w.Write(p)
+	r.err = err
+	return n, err
+}
+
+// Close closes the report file, if any, and returns the first error met while writing the report.
+// Closing more than once is safe.
+func (r *reportWriter) Close() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+		r.file = nil
+	}
+	if r.err != nil {
+		return fmt.Errorf("error writing report: %w", r.err)
+	}
+	return nil
+}
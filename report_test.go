@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"publicprs/pkg/publicprs"
+)
+
+func TestGroupByAuthor(t *testing.T) {
+	prs := []publicprs.PullRequest{
+		{Number: 10, Author: "jdoe"},
+		{Number: 11, Author: "asmith"},
+		{Number: 22, Author: "jdoe"},
+		{Number: 25, Author: "bjones"},
+		{Number: 31, Author: "jdoe"},
+		{Number: 40, Author: "bjones"},
+	}
+
+	groups := groupByAuthor(prs)
+
+	want := []struct {
+		author  string
+		numbers []int
+	}{
+		{"jdoe", []int{10, 22, 31}},
+		{"bjones", []int{25, 40}},
+		{"asmith", []int{11}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		if groups[i].Author != w.author {
+			t.Errorf("group %d author = %q, want %q", i, groups[i].Author, w.author)
+			continue
+		}
+		for j, number := range w.numbers {
+			if groups[i].PullRequests[j].Number != number {
+				t.Errorf("group %q PR %d = #%d, want #%d", w.author, j, groups[i].PullRequests[j].Number, number)
+			}
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestMarkStale(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prs := []publicprs.PullRequest{
+		{Number: 1, CreatedAt: cutoff.Add(-time.Hour)},
+		{Number: 2, CreatedAt: cutoff.Add(time.Hour)},
+		{Number: 3},
+	}
+
+	got := markStale(prs, cutoff)
+	if !got[0].Stale || got[1].Stale || got[2].Stale {
+		t.Errorf("stale = %v, %v, %v, want only the PR created before the cutoff", got[0].Stale, got[1].Stale, got[2].Stale)
+	}
+	if prs[0].Stale {
+		t.Error("markStale() must not modify its argument")
+	}
+	if staleMarker(got[0]) != "[STALE] " || staleMarker(got[1]) != "" {
+		t.Errorf("staleMarker() = %q, %q", staleMarker(got[0]), staleMarker(got[1]))
+	}
+
+	var b strings.Builder
+	writeJSONReport(&b, jsonReport{PullRequests: got})
+	if strings.Count(b.String(), `"stale": true`) != 1 {
+		t.Errorf("JSON report should flag one stale PR:\n%s", b.String())
+	}
+}
+
+func TestAnonymizeAuthors(t *testing.T) {
+	prs := []publicprs.PullRequest{
+		{Number: 1, Author: "jdoe", HeadOwner: "jdoe"},
+		{Number: 2, Author: "jdoe", HeadOwner: "rancher"},
+		{Number: 3, Author: publicprs.DeletedUser},
+	}
+
+	got := anonymizeAuthors(prs)
+	if got[0].Author != "d30a5f57" {
+		t.Errorf("author = %q, want the first 8 hex digits of the SHA-256 of the login", got[0].Author)
+	}
+	if got[1].Author != got[0].Author || got[0].HeadOwner != got[0].Author || got[1].HeadOwner != "rancher" {
+		t.Errorf("got %+v, want the same hash for the same author and only their fork anonymized", got)
+	}
+	if got[2].Author != publicprs.DeletedUser {
+		t.Errorf("deleted author = %q", got[2].Author)
+	}
+	if prs[0].Author != "jdoe" {
+		t.Error("anonymizeAuthors() must not modify its argument")
+	}
+	if groups := groupByAuthor(got); len(groups) != 2 {
+		t.Errorf("got %d author groups, want 2", len(groups))
+	}
+}
+
+func TestReportWriterError(t *testing.T) {
+	out := &reportWriter{w: failingWriter{}}
+	fmt.Fprintf(out, "PR #%d\n", 1)
+	fmt.Fprintf(out, "PR #%d\n", 2)
+
+	err := out.Close()
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Close() error = %v, want the write error", err)
+	}
+}
+
+func TestWriteJSONReportEmpty(t *testing.T) {
+	var b strings.Builder
+	writeJSONReport(&b, jsonReport{Summary: reportSummary{Scanned: 3}})
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, b.String())
+	}
+	if got["schemaVersion"] != float64(reportSchemaVersion) {
+		t.Errorf("schemaVersion = %v, want %d", got["schemaVersion"], reportSchemaVersion)
+	}
+	if prs, ok := got["pullRequests"].([]interface{}); !ok || len(prs) != 0 {
+		t.Errorf("pullRequests = %v, want an empty list", got["pullRequests"])
+	}
+	if _, ok := got["authors"]; ok {
+		t.Errorf("authors should be omitted without -groupby")
+	}
+}
+
+func TestWriteMarkdownReport(t *testing.T) {
+	prs := []publicprs.PullRequest{{
+		Repo:      "dashboard",
+		Number:    7,
+		Title:     "Fix a | b",
+		URL:       "https://github.com/rancher/dashboard/pull/7",
+		Author:    "jdoe",
+		CreatedAt: time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC),
+	}}
+
+	var buf strings.Builder
+	writeMarkdownReport(&buf, prs, true)
+	want := "| PR | Author | Title | Created |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| [dashboard#7](https://github.com/rancher/dashboard/pull/7) | @jdoe | Fix a \\| b | 2024-03-05 |\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestPrintOrgCounts(t *testing.T) {
+	prs := []publicprs.PullRequest{
+		{Number: 1, AuthorOrgs: []string{"partner"}},
+		{Number: 2, AuthorOrgs: []string{"partner", "vendor"}},
+		{Number: 3},
+	}
+
+	var buf strings.Builder
+	printOrgCounts(&buf, []string{"partner", "vendor", "other"}, prs)
+	want := "\nPRs by members of partner organizations:\npartner: 2\nvendor: 1\nother: 0\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestBodySummary(t *testing.T) {
+	if got := bodySummary(""); got != "none" {
+		t.Errorf("bodySummary() of an empty body = %q, want none", got)
+	}
+	if got, want := bodySummary("Fixes the login page.\r\n\r\n- [x] Tests added\n"), "Fixes the login page. - [x] Tests added"; got != want {
+		t.Errorf("bodySummary() = %q, want %q", got, want)
+	}
+	long := bodySummary(strings.Repeat("é", maxBodySummary+1))
+	if want := strings.Repeat("é", maxBodySummary) + "..."; long != want {
+		t.Errorf("bodySummary() of a long body = %q, want it cut at %d characters", long, maxBodySummary)
+	}
+}
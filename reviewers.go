@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"publicprs/pkg/publicprs"
+)
+
+// reviewerRotation requests reviews of newly added PRs for -requestreviewers, from one of its
+// reviewers per PR.  The reviewer is picked by PR number, so the load is spread over the
+// reviewers and each PR gets the same reviewer on every run.  It is safe for concurrent use.
+type reviewerRotation struct {
+	reviewers []string
+
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+func newReviewerRotation(reviewers []string) *reviewerRotation {
+	return &reviewerRotation{reviewers: reviewers, ids: make(map[string]string)}
+}
+
+// pick returns the reviewer for pr, skipping its author, who cannot review their own PR.  It returns
+// an empty string when a review was already requested from one of the reviewers, or when the author
+// is the only reviewer.
+func (r *reviewerRotation) pick(pr publicprs.PullRequest) string {
+	for _, reviewer := range r.reviewers {
+		if slices.ContainsFunc(pr.RequestedReviewers, func(requested string) bool { return strings.EqualFold(requested, reviewer) }) {
+			return ""
+		}
+	}
+	for i := range r.reviewers {
+		reviewer := r.reviewers[(pr.Number+i)%len(r.reviewers)]
+		if !strings.EqualFold(reviewer, pr.Author) {
+			return reviewer
+		}
+	}
+	return ""
+}
+
+// userID returns the global ID of reviewer, looking each one up once per run.
+func (r *reviewerRotation) userID(ctx context.Context, client *publicprs.Client, reviewer string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.ids[reviewer]; ok {
+		return id, nil
+	}
+	id, err := publicprs.UserID(ctx, client, reviewer)
+	if err != nil {
+		return "", err
+	}
+	r.ids[reviewer] = id
+	return id, nil
+}
+
+// request requests a review of pr, which was just added to a project, describing it on w, or only
+// describes it in a dry run.  Failures are logged and don't stop the run.
+func (r *reviewerRotation) request(ctx context.Context, client *publicprs.Client, cfg config, w io.Writer, pr publicprs.PullRequest) {
+	reviewer := r.pick(pr)
+	if reviewer == "" {
+		slog.Debug("Not requesting a review, one of the reviewers is already requested or the author is the only one", "pr", pr.Number)
+		return
+	}
+	if cfg.dryRun {
+		fmt.Fprintf(w, "Would request a review of PR #%d from %s (dry run)\n", pr.Number, reviewer)
+		return
+	}
+	userID, err := r.userID(ctx, client, reviewer)
+	if err != nil {
+		slog.Error("Error requesting review", "pr", pr.Number, "reviewer", reviewer, "err", err)
+		return
+	}
+	prID, err := publicprs.ResolvePullRequestID(ctx, client, pr)
+	if err == nil {
+		err = publicprs.RequestReviews(ctx, client, prID, []string{userID})
+	}
+	if err != nil {
+		slog.Error("Error requesting review", "pr", pr.Number, "reviewer", reviewer, "err", err)
+		return
+	}
+	fmt.Fprintf(w, "Requested a review of PR #%d from %s\n", pr.Number, reviewer)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"publicprs/pkg/publicprs"
+)
+
+func TestReviewerRotationPick(t *testing.T) {
+	r := newReviewerRotation([]string{"alice", "bob", "carol"})
+	tests := []struct {
+		name string
+		pr   publicprs.PullRequest
+		want string
+	}{
+		{name: "by number", pr: publicprs.PullRequest{Number: 4, Author: "jdoe"}, want: "bob"},
+		{name: "next number", pr: publicprs.PullRequest{Number: 5, Author: "jdoe"}, want: "carol"},
+		{name: "author skipped", pr: publicprs.PullRequest{Number: 4, Author: "Bob"}, want: "carol"},
+		{name: "already requested", pr: publicprs.PullRequest{Number: 4, Author: "jdoe", RequestedReviewers: []string{"rancher/ui", "Alice"}}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.pick(tt.pr); got != tt.want {
+				t.Errorf("pick() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := newReviewerRotation([]string{"jdoe"}).pick(publicprs.PullRequest{Number: 1, Author: "jdoe"}); got != "" {
+		t.Errorf("pick() with the author as the only reviewer = %q, want none", got)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"publicprs/pkg/publicprs"
+)
+
+// defaultSlackStatePath returns the file recording the PRs already posted to Slack when
+// -slackstate is not given.
+func defaultSlackStatePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "publicprs", "slack-notified.json"), nil
+}
+
+// notifySlack posts every PR not yet recorded in the state file at statePath to the Slack incoming
+// webhook, then records the PRs that were posted.  When the state file does not exist yet, the PRs
+// are recorded without posting, so the first run does not announce every open PR.
+// It returns the number of PRs posted.
+func notifySlack(ctx context.Context, client *http.Client, webhookURL, statePath string, pullRequests []publicprs.PullRequest) (int, error) {
+	notified, exists, err := loadPRSet(statePath)
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		for _, pr := range pullRequests {
+			notified[prKey(pr)] = true
+		}
+		slog.Info("No Slack state file yet, recording the current PRs without posting them", "path", statePath, "count", len(notified))
+		return 0, savePRSet(statePath, notified)
+	}
+
+	posted := 0
+	for _, pr := range pullRequests {
+		key := prKey(pr)
+		if notified[key] {
+			continue
+		}
+		if err := postSlackMessage(ctx, client, webhookURL, slackMessage(pr)); err != nil {
+			// Leave the PR out of the state so the next run tries again
+			slog.Error("Error posting PR to Slack", "pr", key, "err", err)
+			continue
+		}
+		notified[key] = true
+		posted++
+	}
+
+	return posted, savePRSet(statePath, notified)
+}
+
+// slackMessage formats the Slack announcement of an external PR, using Slack's link markup.
+func slackMessage(pr publicprs.PullRequest) string {
+	return fmt.Sprintf("New external PR <%s|%s#%d> by %s: %s", pr.URL, pr.NameWithOwner(), pr.Number, pr.Author, pr.Title)
+}
+
+// postSlackMessage sends text to a Slack incoming webhook.
+func postSlackMessage(ctx context.Context, client *http.Client, webhookURL, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error: received non-OK response %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"publicprs/pkg/publicprs"
+)
+
+func TestNotifySlack(t *testing.T) {
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct{ Text string }
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding Slack payload: %v", err)
+		}
+		if strings.Contains(payload.Text, "#13") {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+			return
+		}
+		messages = append(messages, payload.Text)
+	}))
+	defer server.Close()
+
+	statePath := filepath.Join(t.TempDir(), "slack.json")
+	prs := []publicprs.PullRequest{{Owner: "rancher", Repo: "rancher", Number: 10, Author: "jdoe", Title: "Fix typo"}}
+
+	// The first run only records the current PRs
+	posted, err := notifySlack(context.Background(), server.Client(), server.URL, statePath, prs)
+	if err != nil || posted != 0 || len(messages) != 0 {
+		t.Fatalf("first run posted %d (%v), error = %v; want nothing posted", posted, messages, err)
+	}
+
+	prs = append(prs,
+		publicprs.PullRequest{Owner: "rancher", Repo: "rancher", Number: 12, Author: "asmith", Title: "Add docs"},
+		publicprs.PullRequest{Owner: "rancher", Repo: "rancher", Number: 13, Author: "bjones", Title: "Rejected"},
+	)
+	posted, err = notifySlack(context.Background(), server.Client(), server.URL, statePath, prs)
+	if err != nil {
+		t.Fatalf("notifySlack() error = %v", err)
+	}
+	if posted != 1 || len(messages) != 1 || !strings.Contains(messages[0], "rancher/rancher#12> by asmith: Add docs") {
+		t.Errorf("posted %d: %q, want only PR #12", posted, messages)
+	}
+
+	state, _, err := loadPRSet(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range state {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	if want := []string{"rancher/rancher#10", "rancher/rancher#12"}; !slices.Equal(keys, want) {
+		t.Errorf("state = %v, want %v; failed posts must be retried", keys, want)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"publicprs/pkg/publicprs"
+)
+
+// prKey identifies a PR in the state files, which may cover several repositories.
+func prKey(pr publicprs.PullRequest) string {
+	return fmt.Sprintf("%s#%d", pr.NameWithOwner(), pr.Number)
+}
+
+// loadPRSet reads a set of PR keys written by savePRSet.  The second return value is false when
+// the file does not exist yet.
+func loadPRSet(path string) (map[string]bool, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]bool), false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading state file: %w", err)
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, false, fmt.Errorf("error parsing state file %s: %w", path, err)
+	}
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set, true, nil
+}
+
+// savePRSet writes a set of PR keys to path as a sorted JSON list.
+func savePRSet(path string, set map[string]bool) error {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over path, so
+// readers never see a partially written file and a failed write leaves the old file intact.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
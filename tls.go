@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"publicprs/pkg/publicprs"
+)
+
+// newBaseTransport returns the transport underlying every request to GitHub and Slack.  It is a
+// copy of the default transport, so proxies are taken from HTTPS_PROXY, HTTP_PROXY and NO_PROXY,
+// with the certificates of the caCertFile PEM bundle trusted on top of the system pool when set.
+// Up to idleConns connections per host are kept open for idleTimeout: the default transport keeps
+// only 2, so concurrent member fetches and additions would otherwise keep opening new connections
+// to api.github.com over the hundreds of requests of a large scan.
+func newBaseTransport(caCertFile string, idleConns int, idleTimeout time.Duration) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.MaxIdleConnsPerHost = idleConns
+	transport.MaxIdleConns = max(transport.MaxIdleConns, idleConns)
+	transport.IdleConnTimeout = idleTimeout
+	if caCertFile == "" {
+		return transport, nil
+	}
+
+	pem, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading -cacert: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("invalid -cacert: no PEM certificates found")
+	}
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return transport, nil
+}
+
+// newRESTClient returns the client for requests that must not carry the GitHub token:
+// resolveToken exchanges GitHub App credentials for a token with it, and notifySlack posts to the
+// Slack webhook with it.
+func newRESTClient(cfg config, base http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:   cfg.httpTimeout,
+		Transport: publicprs.NewRetryTransport(base, cfg.maxRetries),
+	}
+}
+
+// newAPIClient returns the client authenticating every GitHub API request with token.  oauth2
+// sends the requests through the client found in the context.  Dumps are taken below oauth2 so
+// they show the request as sent, with the token scrubbed.
+func newAPIClient(ctx context.Context, cfg config, base http.RoundTripper, token string) (*http.Client, error) {
+	apiTransport := base
+	if cfg.dumpResponses != "" {
+		dump, err := newDumpTransport(base, cfg.dumpResponses)
+		if err != nil {
+			return nil, err
+		}
+		apiTransport = dump
+	}
+	oauthCtx := context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: apiTransport})
+	httpClient := oauth2.NewClient(oauthCtx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	))
+	httpClient.Timeout = cfg.httpTimeout
+	httpClient.Transport = publicprs.NewRetryTransport(httpClient.Transport, cfg.maxRetries)
+	return httpClient, nil
+}
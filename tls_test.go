@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewBaseTransportCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// Without the server's CA the request fails, with it the request succeeds
+	transport, err := newBaseTransport("", 16, 90*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&http.Client{Transport: transport}).Get(server.URL); err == nil {
+		t.Fatal("request to a server with an unknown CA should fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	transport, err = newBaseTransport(path, 16, 90*time.Second)
+	if err != nil {
+		t.Fatalf("newBaseTransport() error = %v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request with the CA bundle failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := newBaseTransport(filepath.Join(t.TempDir(), "missing.pem"), 16, 90*time.Second); err == nil {
+		t.Error("newBaseTransport() with a missing bundle should fail")
+	}
+}
+
+func TestClientsShareTransport(t *testing.T) {
+	var conns, authorized atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer secret" {
+			authorized.Add(1)
+		}
+		io.WriteString(w, "{}")
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	cfg := config{httpTimeout: time.Second, maxRetries: 0}
+	transport, err := newBaseTransport("", 16, 90*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiClient, err := newAPIClient(context.Background(), cfg, transport, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sequential requests through both clients reuse the single connection of the shared transport
+	for _, client := range []*http.Client{newRESTClient(cfg, transport), apiClient, apiClient} {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if got := conns.Load(); got != 1 {
+		t.Errorf("connections = %d, want 1", got)
+	}
+	if got := authorized.Load(); got != 2 {
+		t.Errorf("authorized requests = %d, want 2", got)
+	}
+	if transport.MaxIdleConnsPerHost != 16 || transport.IdleConnTimeout != 90*time.Second {
+		t.Errorf("transport keeps %d idle connections for %s, want 16 for 1m30s", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+}